@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"net/http"
@@ -11,7 +12,9 @@ import (
 	"nexus-pusher/pkg/logger"
 	"nexus-pusher/pkg/metrics"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 )
 
 // App version
@@ -33,6 +36,27 @@ func main() {
 		log.Fatalf("args is nil")
 	}
 
+	// Run offline bundle export. All components data is taken from diff file,
+	// client config is optional and it's used for artifacts source credentials only
+	if args.Export != "" {
+		if args.Diff == "" {
+			log.Fatalf("'--diff' file is required for '--export'")
+		}
+		var clientConfig *config.Client
+		if args.ConfigSet {
+			cfg := config.NewNexusConfig()
+			if err := cfg.LoadConfig(args.ConfigPath); err != nil {
+				log.Fatalf("unable to load config: %v", err)
+			}
+			clientConfig = cfg.Client
+		}
+		log.WithFields(log.Fields{"diff": args.Diff, "bundle": args.Export}).Info("Running offline bundle export.")
+		if err := client.RunExport(args.Diff, args.Export, clientConfig); err != nil {
+			log.Fatalf("unable to export bundle: %v", err)
+		}
+		return
+	}
+
 	// Load Nexus-Pusher configuration from file
 	cfg := config.NewNexusConfig()
 	if err := cfg.LoadConfig(args.ConfigPath); err != nil {
@@ -91,8 +115,37 @@ func main() {
 			strconv.Itoa(cfg.Client.Daemon.SyncEveryMinutes),
 		).Set(1)
 
+		// Cancel running syncs on interrupt
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			log.Warnf("Shutdown signal received, canceling running syncs...")
+			// Restore default behavior to allow force exit with second signal
+			stop()
+		}()
+
 		// Create new nexus-pusher client
-		c := client.NewClient(version, cfg.Client, clientMetrics)
+		c, err := client.NewClient(ctx, version, cfg.Client, clientMetrics)
+		if err != nil {
+			log.Fatalf("unable to create client: %v", err)
+		}
+
+		// Run offline bundle related modes
+		if args.SaveDiff != "" {
+			log.WithFields(log.Fields{"diff": args.SaveDiff}).Info("Saving components diff to file.")
+			if err := c.SaveDiff(args.SaveDiff); err != nil {
+				log.Fatalf("unable to save diff: %v", err)
+			}
+			return
+		}
+		if args.Import != "" {
+			log.WithFields(log.Fields{"bundle": args.Import}).Info("Running offline bundle import.")
+			if err := c.RunImport(args.Import); err != nil {
+				log.Fatalf("unable to import bundle: %v", err)
+			}
+			return
+		}
 
 		if cfg.Client.Daemon.Enabled {
 			syncMinutes := cfg.Client.Daemon.SyncEveryMinutes
@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
 	"github.com/go-co-op/gocron"
 	"github.com/goccy/go-json"
@@ -19,13 +20,30 @@ import (
 )
 
 type client struct {
+	// ctx is canceled on client shutdown
+	ctx     context.Context
 	config  *config.Client
 	metrics *nexusClientMetrics
 	version *core.Version
+	// serverTLS is TLS config of connections to nexus-pusher server, nil for defaults
+	serverTLS *tls.Config
+	// syncs is used to wait running syncs on shutdown
+	syncs *sync.WaitGroup
 }
 
-func NewClient(version *core.Version, config *config.Client, metrics *nexusClientMetrics) *client {
-	return &client{config: config, metrics: metrics, version: version}
+func NewClient(ctx context.Context, version *core.Version, config *config.Client, metrics *nexusClientMetrics) (*client, error) {
+	serverTLS, err := core.TLSConfig(config.ServerTLS)
+	if err != nil {
+		return nil, fmt.Errorf("NewClient: %w", err)
+	}
+	return &client{
+		ctx:       ctx,
+		config:    config,
+		metrics:   metrics,
+		version:   version,
+		serverTLS: serverTLS,
+		syncs:     &sync.WaitGroup{},
+	}, nil
 }
 
 func fileNameFromPath(path string) string { // Get last part of url chunk with filename information
@@ -39,7 +57,7 @@ func compareComponents(src []*core.NexusComponent, dst []*core.NexusComponent) [
 	dstNca := make(map[string]struct{}, len(dst))
 	for _, v := range dst {
 		for _, vv := range v.Assets {
-			dstNca[strings.ToLower(vv.AssetPathWithoutTrailingZeroes())] = struct{}{}
+			dstNca[vv.ComparisonKey()] = struct{}{}
 		}
 	}
 
@@ -48,19 +66,49 @@ func compareComponents(src []*core.NexusComponent, dst []*core.NexusComponent) [
 	for i, v := range src {
 		var nca []*core.NexusComponentAsset
 		for ii, vv := range v.Assets {
-			if _, ok := dstNca[strings.ToLower(vv.AssetPathWithoutTrailingZeroes())]; !ok {
+			if _, ok := dstNca[vv.ComparisonKey()]; !ok {
 				nca = append(nca, v.Assets[ii])
 			}
 		}
 		if len(nca) != 0 {
-			tmpSrc := src[i]
+			// Copy component to keep original assets list untouched
+			tmpSrc := *src[i]
 			tmpSrc.Assets = nca
-			nc = append(nc, tmpSrc)
+			nc = append(nc, &tmpSrc)
 		}
 	}
 	return nc
 }
 
+// mirrorComponents will return dst components which assets are all missing in src
+func mirrorComponents(src []*core.NexusComponent, dst []*core.NexusComponent) []*core.NexusComponent {
+	// Make src hash-map
+	srcNca := make(map[string]struct{}, len(src))
+	for _, v := range src {
+		for _, vv := range v.Assets {
+			srcNca[vv.ComparisonKey()] = struct{}{}
+		}
+	}
+
+	// Component is deleted only if none of its assets are left in src,
+	// otherwise it would be uploaded again at next sync
+	var nc []*core.NexusComponent
+Outer:
+	for _, v := range dst {
+		// Component without assets after filter (i.e. only hash files are left) can't be matched with src
+		if len(v.Assets) == 0 {
+			continue
+		}
+		for _, vv := range v.Assets {
+			if _, ok := srcNca[vv.ComparisonKey()]; ok {
+				continue Outer
+			}
+		}
+		nc = append(nc, v)
+	}
+	return nc
+}
+
 func (nc client) doCompareComponents(
 	s1 *core.NexusServer,
 	c1 *http.Client,
@@ -69,7 +117,23 @@ func (nc client) doCompareComponents(
 	c2 *http.Client,
 	r2 string,
 ) ([]*core.NexusComponent, error) {
-	ctx, cancel := context.WithCancel(context.Background())
+	src, dst, err := nc.doGetComponents(s1, c1, r1, s2, c2, r2)
+	if err != nil {
+		return nil, fmt.Errorf("doCompareComponents: %w", err)
+	}
+	return compareComponents(src, dst), nil
+}
+
+// doGetComponents will get components of src and dst repositories simultaneously
+func (nc client) doGetComponents(
+	s1 *core.NexusServer,
+	c1 *http.Client,
+	r1 string,
+	s2 *core.NexusServer,
+	c2 *http.Client,
+	r2 string,
+) ([]*core.NexusComponent, []*core.NexusComponent, error) {
+	ctx, cancel := context.WithCancel(nc.ctx)
 	group, errCtx := errgroup.WithContext(ctx)
 	var src, dst []*core.NexusComponent
 	tn := time.Now()
@@ -101,8 +165,8 @@ func (nc client) doCompareComponents(
 
 	// Check for errors in requests
 	if err := group.Wait(); err != nil {
-		return nil, &utils.ContextError{
-			Context: "doCompareComponents",
+		return nil, nil, &utils.ContextError{
+			Context: "doGetComponents",
 			Err: fmt.Errorf("unable to compare source repository '%s' at server '%s' "+
 				"with destination repository '%s' at server '%s' because of error: %v", r1, s1.Host, r2, s2.Host, err),
 		}
@@ -113,7 +177,7 @@ func (nc client) doCompareComponents(
 	// Update metric for total destination repo assets count
 	nc.metrics.LastDstAssetsCountByLabels(s2.Host, r2).Set(float64(len(dst)))
 
-	return compareComponents(src, dst), nil
+	return src, dst, nil
 }
 
 func showFinalMessageForGetComponents(repo string, server string, nc []*core.NexusComponent, t time.Time) {
@@ -126,6 +190,13 @@ func showFinalMessageForGetComponents(repo string, server string, nc []*core.Nex
 
 // RunNexusPusher client entry point
 func (nc client) RunNexusPusher() {
+	// Don't start new sync on shutdown
+	if nc.ctx.Err() != nil {
+		return
+	}
+	nc.syncs.Add(1)
+	defer nc.syncs.Done()
+
 	// Check nexus-pusher server status
 	if err := nc.doCheckServerStatus(); err != nil {
 		log.Errorf("server status check failed: %v", err)
@@ -170,7 +241,12 @@ func (nc client) ScheduleRunNexusPusher(interval interface{}) error {
 	if err != nil {
 		return fmt.Errorf("can't schedule sync. job: %v: error: %w", j, err)
 	}
-	s.StartBlocking()
+	s.StartAsync()
+
+	// Wait for shutdown and let running syncs cancel their server requests
+	<-nc.ctx.Done()
+	s.Stop()
+	nc.syncs.Wait()
 
 	return nil
 }
@@ -179,7 +255,7 @@ func (nc client) doCheckServerStatus() error {
 	// Create URL for status checking
 	srvUrl := fmt.Sprintf("%s%s%s", nc.config.Server, config.URIBase, config.URIStatus)
 	// Define client
-	c := http_clients.HttpRetryClient()
+	c := http_clients.HttpRetryClientTLS(nc.serverTLS)
 
 	req, err := http.NewRequest("GET", srvUrl, nil)
 	if err != nil {
@@ -212,7 +288,7 @@ func (nc client) doCheckServerVersion() error {
 	// Create URL for status checking
 	srvUrl := fmt.Sprintf("%s%s%s", nc.config.Server, config.URIBase, config.URIVersion)
 	// Define client
-	client := http_clients.HttpRetryClient()
+	client := http_clients.HttpRetryClientTLS(nc.serverTLS)
 	// Create request
 	req, err := http.NewRequest("GET", srvUrl, nil)
 	if err != nil {
@@ -262,6 +338,19 @@ func (nc client) doCheckServerVersion() error {
 	return nil
 }
 
+// serversTLS returns TLS configs of source and destination servers of sync config
+func serversTLS(sc *config.SyncConfig) (*tls.Config, *tls.Config, error) {
+	srcTLS, err := core.TLSConfig(sc.SrcServerConfig.TLS)
+	if err != nil {
+		return nil, nil, fmt.Errorf("serversTLS: source server %s: %w", sc.SrcServerConfig.Server, err)
+	}
+	dstTLS, err := core.TLSConfig(sc.DstServerConfig.TLS)
+	if err != nil {
+		return nil, nil, fmt.Errorf("serversTLS: destination server %s: %w", sc.DstServerConfig.Server, err)
+	}
+	return srcTLS, dstTLS, nil
+}
+
 func doCheckRepoTypes(sc *config.SyncConfig) error {
 	// Define variables
 	s1 := core.NewNexusServer(sc.SrcServerConfig.User, sc.SrcServerConfig.Pass,
@@ -269,8 +358,12 @@ func doCheckRepoTypes(sc *config.SyncConfig) error {
 	s2 := core.NewNexusServer(sc.DstServerConfig.User, sc.DstServerConfig.Pass,
 		sc.DstServerConfig.Server, config.URIBase, config.URIRepositories)
 
-	c1 := http_clients.HttpRetryClient()
-	c2 := http_clients.HttpRetryClient()
+	srcTLS, dstTLS, err := serversTLS(sc)
+	if err != nil {
+		return fmt.Errorf("doCheckRepoTypes: %w", err)
+	}
+	c1 := http_clients.HttpRetryClientTLS(srcTLS)
+	c2 := http_clients.HttpRetryClientTLS(dstTLS)
 
 	var nr1 []*core.NexusRepository
 	var nr2 []*core.NexusRepository
@@ -374,8 +467,13 @@ func (nc client) doSyncConfigs(cc *config.Client, sc *config.SyncConfig) {
 		sc.SrcServerConfig.Server, config.URIBase, config.URIComponents)
 	s2 := core.NewNexusServer(sc.DstServerConfig.User, sc.DstServerConfig.Pass,
 		sc.DstServerConfig.Server, config.URIBase, config.URIComponents)
-	c1 := http_clients.HttpRetryClient()
-	c2 := http_clients.HttpRetryClient()
+	srcTLS, dstTLS, err := serversTLS(sc)
+	if err != nil {
+		log.Errorf("%v", err)
+		return
+	}
+	c1 := http_clients.HttpRetryClientTLS(srcTLS)
+	c2 := http_clients.HttpRetryClientTLS(dstTLS)
 
 	// Check repos type
 	if err := doCheckRepoTypes(sc); err != nil {
@@ -383,13 +481,23 @@ func (nc client) doSyncConfigs(cc *config.Client, sc *config.SyncConfig) {
 		return
 	}
 
-	// Get repo diff
-	cmpDiff, err := nc.doCompareComponents(s1, c1, sc.SrcServerConfig.RepoName, s2, c2, sc.DstServerConfig.RepoName)
+	// Get repos components
+	src, dst, err := nc.doGetComponents(s1, c1, sc.SrcServerConfig.RepoName, s2, c2, sc.DstServerConfig.RepoName)
 	if err != nil {
 		log.Errorf("%v", err)
 		return
 	}
 
+	// Delete destination components which were removed from source
+	if sc.Mirror.Enabled {
+		if err := doMirrorComponents(sc, s2, c2, src, dst); err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+
+	// Get repo diff
+	cmpDiff := compareComponents(src, dst)
+
 	// Update metric for last sync diff count
 	nc.metrics.LastSyncDiffByLabels(
 		sc.SrcServerConfig.Server,
@@ -408,17 +516,28 @@ func (nc client) doSyncConfigs(cc *config.Client, sc *config.SyncConfig) {
 			sc.DstServerConfig.Server)
 
 		// Convert original nexus json to export type
-		data := genNexExpCompFromNexComp(sc.ArtifactsSource, cmpDiff)
+		data := exportComponents(sc, cmpDiff)
+		if data.Upstreams, err = artifactsSourceUpstreams(sc); err != nil {
+			log.Errorf("%v", err)
+			return
+		}
 		data.NexusServer = core.NexusServer{
 			Host:             sc.DstServerConfig.Server,
 			BaseUrl:          config.URIBase,
 			ApiComponentsUrl: config.URIComponents,
 			Username:         sc.DstServerConfig.User,
 			Password:         sc.DstServerConfig.Pass,
+			DockerConnector:  sc.DstServerConfig.DockerConnector,
+		}
+		// Destination credentials are held by nexus-pusher server profile
+		if sc.DstServerConfig.Profile != "" {
+			data.NexusServer.Username = ""
+			data.NexusServer.Password = ""
+			data.NexusServer.Profile = sc.DstServerConfig.Profile
 		}
 
 		// Send diff data to nexus-pusher server
-		pc := newPushClient(cc.Server, cc.ServerAuth.User, cc.ServerAuth.Pass, nc.metrics)
+		pc := newPushClient(cc.Server, cc.ServerAuth.User, cc.ServerAuth.Pass, nc.serverTLS, nc.metrics)
 
 		// Use basic auth to get JWT token
 		if err := pc.authorize(); err != nil {
@@ -434,7 +553,7 @@ func (nc client) doSyncConfigs(cc *config.Client, sc *config.SyncConfig) {
 		}
 
 		// Start server polling to get request results
-		if err := pc.pollComparedResults(body, sc.DstServerConfig.RepoName, sc.DstServerConfig.Server); err != nil {
+		if err := pc.pollComparedResults(nc.ctx, body, sc.DstServerConfig.RepoName, sc.DstServerConfig.Server); err != nil {
 			log.Errorf("%v", err)
 		}
 	} else {
@@ -447,6 +566,87 @@ func (nc client) doSyncConfigs(cc *config.Client, sc *config.SyncConfig) {
 	}
 }
 
+// doMirrorComponents will delete dst components which are missing in src
+func doMirrorComponents(sc *config.SyncConfig, s *core.NexusServer, c *http.Client,
+	src []*core.NexusComponent, dst []*core.NexusComponent) error {
+	// Empty source is much more likely a misconfiguration than a real cleanup
+	if len(src) == 0 && len(dst) != 0 {
+		return &utils.ContextError{
+			Context: "doMirrorComponents",
+			Err: fmt.Errorf("refusing to mirror empty '%s' repo at server %s to '%s' repo at server %s",
+				sc.SrcServerConfig.RepoName,
+				sc.SrcServerConfig.Server,
+				sc.DstServerConfig.RepoName,
+				sc.DstServerConfig.Server),
+		}
+	}
+
+	toDelete := mirrorComponents(src, dst)
+	if len(toDelete) == 0 {
+		return nil
+	}
+
+	limitErr := &utils.ContextError{
+		Context: "doMirrorComponents",
+		Err: fmt.Errorf("refusing to delete %d components from '%s' repo at server %s: "+
+			"limit of %d deletions per sync is exceeded",
+			len(toDelete),
+			sc.DstServerConfig.RepoName,
+			sc.DstServerConfig.Server,
+			sc.Mirror.MaxDeletions),
+	}
+	// Dry run lists all candidates even if limit is exceeded, so operator could check them
+	if len(toDelete) > sc.Mirror.MaxDeletions && !sc.Mirror.DryRun {
+		return limitErr
+	}
+
+	log.Printf("Found %d components in '%s' repo at server %s which are missing in '%s' repo at server %s:",
+		len(toDelete),
+		sc.DstServerConfig.RepoName,
+		sc.DstServerConfig.Server,
+		sc.SrcServerConfig.RepoName,
+		sc.SrcServerConfig.Server)
+
+	if sc.Mirror.DryRun {
+		for _, v := range toDelete {
+			log.Printf("[dry-run] component '%s' will be deleted", componentFullName(v))
+		}
+		if len(toDelete) > sc.Mirror.MaxDeletions {
+			return limitErr
+		}
+		return nil
+	}
+
+	var deleted int
+	for _, v := range toDelete {
+		if err := s.DeleteComponent(c, v.ID); err != nil {
+			log.Errorf("failed to delete component '%s': %v", componentFullName(v), err)
+			continue
+		}
+		deleted++
+		log.Printf("Component '%s' deleted", componentFullName(v))
+	}
+
+	log.Printf("Deleted %d of %d components from '%s' repo at server %s",
+		deleted,
+		len(toDelete),
+		sc.DstServerConfig.RepoName,
+		sc.DstServerConfig.Server)
+	return nil
+}
+
+// componentFullName will return human-readable component name
+func componentFullName(c *core.NexusComponent) string {
+	name := c.Name
+	if c.Group != "" {
+		name = fmt.Sprintf("%s/%s", c.Group, name)
+	}
+	if c.Version != "" {
+		name = fmt.Sprintf("%s:%s", name, c.Version)
+	}
+	return name
+}
+
 func checkSupportedRepoTypes(repoType config.ComponentType) error {
 	switch repoType.Lower() {
 	case config.NPM:
@@ -457,6 +657,22 @@ func checkSupportedRepoTypes(repoType config.ComponentType) error {
 		return nil
 	case config.NUGET:
 		return nil
+	case config.HELM:
+		return nil
+	case config.DOCKER:
+		return nil
+	case config.RUBY:
+		return nil
+	case config.APT:
+		return nil
+	case config.YUM:
+		return nil
+	case config.RAW:
+		return nil
+	case config.GO:
+		return nil
+	case config.CONDA:
+		return nil
 	default:
 		return &utils.ContextError{
 			Context: "checkSupportedRepoTypes",
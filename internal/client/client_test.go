@@ -1,6 +1,7 @@
 package client
 
 import (
+	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
 	"reflect"
 	"testing"
@@ -228,3 +229,108 @@ func Test_compareComponents(t *testing.T) {
 		})
 	}
 }
+
+func Test_mirrorComponents(t *testing.T) {
+	type args struct {
+		src []*core.NexusComponent
+		dst []*core.NexusComponent
+	}
+	tests := []struct {
+		name string
+		args args
+		want []*core.NexusComponent
+	}{
+		{
+			name: "test1",
+			args: args{src: []*core.NexusComponent{
+				{
+					ID:      "id1",
+					Name:    "name1",
+					Version: "1.0",
+					Assets: []*core.NexusComponentAsset{
+						{Path: "path/file1.tar"},
+					},
+				},
+			},
+				dst: []*core.NexusComponent{
+					{
+						ID:      "id11",
+						Name:    "name1",
+						Version: "1.0",
+						Assets: []*core.NexusComponentAsset{
+							{Path: "Path/file1.tar"},
+							{Path: "path/file1.tar.asc"},
+						},
+					},
+					{
+						ID:      "id12",
+						Name:    "name2",
+						Version: "1.0",
+						Assets: []*core.NexusComponentAsset{
+							{Path: "path/file2.tar"},
+						},
+					},
+				},
+			},
+			want: []*core.NexusComponent{
+				{
+					ID:      "id12",
+					Name:    "name2",
+					Version: "1.0",
+					Assets: []*core.NexusComponentAsset{
+						{Path: "path/file2.tar"},
+					},
+				},
+			},
+		},
+		{
+			name: "test2",
+			args: args{src: []*core.NexusComponent{
+				{
+					ID:      "id1",
+					Name:    "name1",
+					Version: "1.0",
+					Assets: []*core.NexusComponentAsset{
+						{Path: "path/file1.tar"},
+					},
+				},
+			},
+				dst: nil,
+			},
+			want: nil,
+		},
+		{
+			name: "component without assets",
+			args: args{src: []*core.NexusComponent{
+				{ID: "id1", Name: "name1", Version: "1.0", Assets: []*core.NexusComponentAsset{{Path: "path/file1.tar"}}},
+			},
+				dst: []*core.NexusComponent{{ID: "id11", Name: "name1", Version: "1.0"}},
+			},
+			want: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mirrorComponents(tt.args.src, tt.args.dst); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mirrorComponents() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_doMirrorComponents_dryRunLimit(t *testing.T) {
+	sc := &config.SyncConfig{Mirror: config.Mirror{Enabled: true, DryRun: true, MaxDeletions: 1}}
+	src := []*core.NexusComponent{{ID: "id1", Name: "name1", Assets: []*core.NexusComponentAsset{{Path: "file1"}}}}
+	dst := []*core.NexusComponent{
+		{ID: "id2", Name: "name2", Assets: []*core.NexusComponentAsset{{Path: "file2"}}},
+		{ID: "id3", Name: "name3", Assets: []*core.NexusComponentAsset{{Path: "file3"}}},
+	}
+	// Nothing is deleted in dry run, so there is no destination server, but exceeded limit is reported
+	if err := doMirrorComponents(sc, nil, nil, src, dst); err == nil {
+		t.Errorf("doMirrorComponents() error = nil, want limit error")
+	}
+	sc.Mirror.MaxDeletions = 2
+	if err := doMirrorComponents(sc, nil, nil, src, dst); err != nil {
+		t.Errorf("doMirrorComponents() error = %v", err)
+	}
+}
@@ -0,0 +1,263 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"net/http"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/server"
+	"nexus-pusher/pkg/http_clients"
+	"nexus-pusher/pkg/utils"
+	"time"
+)
+
+// errEventsNotSupported is returned for servers started before job events stream was introduced
+var errEventsNotSupported = errors.New("job events stream is not supported by server")
+
+var (
+	// Wait for job results maximum for 60 min
+	eventsLimitTime = 60 * time.Minute
+	// Reconnect stream if nothing (including server heartbeats) was received for this time
+	eventsIdleTimeout = 1 * time.Minute
+	// Delay between stream reconnects
+	eventsReconnectDelay = 1 * time.Second
+	// Maximum count of stream reconnects in a row without any event received
+	eventsMaxReconnects = 10
+)
+
+const (
+	// Single event holds whole job response with all errors, so limit it like server request body
+	eventsMaxLineSize = 30 << 20
+	// Report upload progress every 30 seconds
+	eventsProgressInterval = 30 * time.Second
+)
+
+// sseEvent is a single Server-Sent Event
+type sseEvent struct {
+	id   string
+	name string
+	data []byte
+}
+
+// streamJobEvents will read job events stream until complete job message is received.
+// Stream is reconnected with Last-Event-ID when connection is lost
+func (p *pushClient) streamJobEvents(ctx context.Context, msg *server.Message, dstRepo string,
+	dstServer string) (*server.Message, error) {
+	requestUrl := fmt.Sprintf("%s%s%s/%s%s",
+		p.serverAddress,
+		config.URIBase,
+		config.URIJobs,
+		msg.ID,
+		config.URIEvents)
+
+	// Stream is long-living, so it's limited by context instead of client timeout
+	client := http_clients.HttpClientTLS(p.tls, 0)
+	streamCtx, cancel := context.WithTimeout(ctx, eventsLimitTime)
+	defer cancel()
+
+	var lastEventID string
+	var result *server.Message
+	var lastReport time.Time
+	handle := func(ev *sseEvent) (bool, error) {
+		if ev.id != "" {
+			lastEventID = ev.id
+		}
+		switch ev.name {
+		case server.EventResult:
+			re := &server.ResultEvent{}
+			if err := json.Unmarshal(ev.data, re); err != nil {
+				return false, fmt.Errorf("handle: %w", err)
+			}
+			if re.Error != "" {
+				log.WithFields(log.Fields{"id": msg.ID}).Debugf("Asset upload failed: %s: %s", re.Path, re.Error)
+			} else {
+				log.WithFields(log.Fields{"id": msg.ID}).Debugf("Asset uploaded: %s", re.Path)
+			}
+			// Update upload progress metrics and report it every 30 seconds
+			p.updateProgressMetrics(re.Progress, dstRepo, dstServer)
+			if time.Since(lastReport) >= eventsProgressInterval {
+				lastReport = time.Now()
+				logProgress(&server.Message{ID: msg.ID, State: server.JobRunning, Progress: re.Progress},
+					dstRepo, dstServer)
+			}
+		case server.EventComplete:
+			result = &server.Message{}
+			if err := json.Unmarshal(ev.data, result); err != nil {
+				return false, fmt.Errorf("handle: %w", err)
+			}
+			p.updateProgressMetrics(result.Progress, dstRepo, dstServer)
+			return true, nil
+		}
+		return false, nil
+	}
+
+	for reconnects := 0; ; reconnects++ {
+		received, err := p.readJobEvents(streamCtx, client, requestUrl, lastEventID, handle)
+		if result != nil {
+			return result, nil
+		}
+		if errors.Is(err, errEventsNotSupported) {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("streamJobEvents: %w", ctx.Err())
+		}
+		if streamCtx.Err() != nil {
+			return nil, &utils.ContextError{
+				Context: "streamJobEvents",
+				Err:     fmt.Errorf("unable to get results for message id %s in %v", msg.ID, eventsLimitTime),
+			}
+		}
+		if received {
+			reconnects = 0
+		}
+		if reconnects >= eventsMaxReconnects {
+			return nil, fmt.Errorf("streamJobEvents: %w", err)
+		}
+		log.WithFields(
+			log.Fields{"id": msg.ID},
+		).Warnf("Job events stream interrupted: %v. Reconnecting...", err)
+
+		select {
+		case <-streamCtx.Done():
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
+
+// readJobEvents will connect to job events stream and pass received events to handler
+// until it reports the stream is done. It returns whether any event was received
+func (p *pushClient) readJobEvents(ctx context.Context, client *http.Client, requestUrl string, lastEventID string,
+	handle func(ev *sseEvent) (bool, error)) (bool, error) {
+	// Make sure JWT token is still valid
+	if err := p.ensureAuth(); err != nil {
+		return false, fmt.Errorf("readJobEvents: %w", err)
+	}
+
+	// Abort connection if server is not responding
+	connCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	idle := time.AfterFunc(eventsIdleTimeout, cancel)
+	defer idle.Stop()
+
+	req, err := http.NewRequestWithContext(connCtx, "GET", requestUrl, nil)
+	if err != nil {
+		return false, fmt.Errorf("readJobEvents: %w", err)
+	}
+	req.Header.Set("Accept", "text/event-stream")
+	if lastEventID != "" {
+		req.Header.Set("Last-Event-ID", lastEventID)
+	}
+	// Append JWT auth Cookie
+	req.AddCookie(p.cookie)
+
+	// Send request
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, fmt.Errorf("readJobEvents: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check server response
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound, http.StatusMethodNotAllowed:
+		return false, errEventsNotSupported
+	default:
+		return false, &utils.ContextError{
+			Context: "readJobEvents",
+			Err:     fmt.Errorf("error: %s responded with status: %s", p.serverAddress, resp.Status),
+		}
+	}
+
+	var received bool
+	err = readEvents(&idleReader{r: resp.Body, timer: idle, timeout: eventsIdleTimeout}, func(ev *sseEvent) (bool, error) {
+		received = true
+		return handle(ev)
+	})
+	if err != nil {
+		return received, fmt.Errorf("readJobEvents: %w", err)
+	}
+	return received, nil
+}
+
+// ensureAuth will refresh JWT token or authorize again if token is already expired
+func (p *pushClient) ensureAuth() error {
+	if p.cookie == nil || time.Now().After(p.cookie.Expires) {
+		if err := p.authorize(); err != nil {
+			return fmt.Errorf("ensureAuth: %w", err)
+		}
+		return nil
+	}
+	if err := p.refreshAuth(); err != nil {
+		return fmt.Errorf("ensureAuth: %w", err)
+	}
+	return nil
+}
+
+// readEvents will parse Server-Sent Events stream and pass every event to fn until it reports stream is done.
+// io.ErrUnexpectedEOF is returned if stream is closed before that
+func readEvents(r io.Reader, fn func(ev *sseEvent) (bool, error)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), eventsMaxLineSize)
+	ev := &sseEvent{}
+	var data [][]byte
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		// Blank line dispatches collected event
+		if len(line) == 0 {
+			if data != nil {
+				ev.data = bytes.Join(data, []byte("\n"))
+				done, err := fn(ev)
+				if err != nil {
+					return fmt.Errorf("readEvents: %w", err)
+				}
+				if done {
+					return nil
+				}
+			}
+			ev = &sseEvent{}
+			data = nil
+			continue
+		}
+		// Skip comments (server heartbeats)
+		if line[0] == ':' {
+			continue
+		}
+		field, value := line, []byte(nil)
+		if i := bytes.IndexByte(line, ':'); i >= 0 {
+			field, value = line[:i], bytes.TrimPrefix(line[i+1:], []byte(" "))
+		}
+		switch string(field) {
+		case "id":
+			ev.id = string(value)
+		case "event":
+			ev.name = string(value)
+		case "data":
+			data = append(data, append([]byte(nil), value...))
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("readEvents: %w", err)
+	}
+	return fmt.Errorf("readEvents: %w", io.ErrUnexpectedEOF)
+}
+
+// idleReader is resetting idle timer on every read
+type idleReader struct {
+	r       io.Reader
+	timer   *time.Timer
+	timeout time.Duration
+}
+
+func (i *idleReader) Read(p []byte) (int, error) {
+	n, err := i.r.Read(p)
+	i.timer.Reset(i.timeout)
+	return n, err
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/server"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_readEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		stream  string
+		want    []sseEvent
+		wantErr error
+	}{
+		{
+			name:   "events with heartbeat",
+			stream: "id: 1\nevent: result\ndata: {}\n\n: heartbeat\n\nid: 2\nevent: complete\ndata: {\"id\":1}\n\n",
+			want: []sseEvent{
+				{id: "1", name: "result", data: []byte("{}")},
+				{id: "2", name: "complete", data: []byte(`{"id":1}`)},
+			},
+		},
+		{
+			name:   "multi-line data",
+			stream: "event: complete\ndata:line1\ndata: line2\n\n",
+			want:   []sseEvent{{name: "complete", data: []byte("line1\nline2")}},
+		},
+		{
+			name:    "stream closed before complete",
+			stream:  "id: 1\nevent: result\ndata: {}\n\nid: 2\nevent: result\n",
+			want:    []sseEvent{{id: "1", name: "result", data: []byte("{}")}},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []sseEvent
+			err := readEvents(strings.NewReader(tt.stream), func(ev *sseEvent) (bool, error) {
+				got = append(got, *ev)
+				return ev.name == server.EventComplete, nil
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("readEvents() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readEvents() got = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_pushClient_streamJobEvents(t *testing.T) {
+	id := uuid.New()
+	var lastEventIDs []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != config.URIBase+config.URIJobs+"/"+id.String()+config.URIEvents {
+			http.NotFound(w, r)
+			return
+		}
+		lastEventIDs = append(lastEventIDs, r.Header.Get("Last-Event-ID"))
+		w.Header().Set("Content-Type", "text/event-stream")
+		// Connection is lost after first event
+		if len(lastEventIDs) == 1 {
+			fmt.Fprint(w, "id: 1\nevent: result\ndata: {\"path\":\"path/file1.tgz\"}\n\n")
+			return
+		}
+		fmt.Fprint(w, "id: 2\nevent: result\ndata: {\"path\":\"path/file2.tgz\",\"error\":\"some error\"}\n\n")
+		fmt.Fprintf(w, "id: 3\nevent: complete\ndata: {\"id\":\"%s\",\"state\":\"failed\",\"response\":[\"some error\"],\"complete\":true}\n\n", id)
+	}))
+	defer srv.Close()
+
+	defer func(d time.Duration) { eventsReconnectDelay = d }(eventsReconnectDelay)
+	eventsReconnectDelay = time.Millisecond
+	p := newPushClient(srv.URL, "user", "pass", nil, NewMetrics(prometheus.NewRegistry()))
+	p.cookie = &http.Cookie{Name: config.JWTCookieName, Expires: time.Now().Add(time.Hour)}
+
+	got, err := p.streamJobEvents(context.Background(), &server.Message{ID: id}, "repo1", "https://nexus.some")
+	if err != nil {
+		t.Fatalf("streamJobEvents() error = %v", err)
+	}
+	want := &server.Message{ID: id, State: server.JobFailed, Response: []string{"some error"}, Complete: true}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("streamJobEvents() got = %+v, want %+v", got, want)
+	}
+	if !reflect.DeepEqual(lastEventIDs, []string{"", "1"}) {
+		t.Errorf("streamJobEvents() sent Last-Event-ID = %v, want [\"\" \"1\"]", lastEventIDs)
+	}
+
+	// Server without job events stream
+	_, err = p.streamJobEvents(context.Background(), &server.Message{ID: uuid.New()}, "repo1", "https://nexus.some")
+	if !errors.Is(err, errEventsNotSupported) {
+		t.Errorf("streamJobEvents() error = %v, want %v", err, errEventsNotSupported)
+	}
+}
@@ -1,11 +1,24 @@
 package client
 
 import (
+	"fmt"
+	"net/url"
+	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
+	"strings"
 )
 
 // genNexExpCompFromNexComp is converting original nexus structure data to compact export format
-func genNexExpCompFromNexComp(artifactsSource string, c []*core.NexusComponent) *core.NexusExportComponents {
+func genNexExpCompFromNexComp(artifactsSources []string, c []*core.NexusComponent) *core.NexusExportComponents {
+	// The first source is primary one, others are fallbacks
+	var primary string
+	var fallbacks []string
+	if len(artifactsSources) != 0 {
+		primary = artifactsSources[0]
+	}
+	if len(artifactsSources) > 1 {
+		fallbacks = artifactsSources[1:]
+	}
 	ec := make([]*core.NexusExportComponent, 0, len(c))
 	for _, v := range c {
 		var assets []*core.NexusExportComponentAsset
@@ -24,10 +37,86 @@ func genNexExpCompFromNexComp(artifactsSource string, c []*core.NexusComponent)
 			Repository:      v.Repository,
 			Format:          v.Format,
 			Group:           v.Group,
-			ArtifactsSource: artifactsSource,
+			ArtifactsSource: primary,
 			Assets:          assets,
+			FallbackSources: fallbacks,
 		}
 		ec = append(ec, exportComponent)
 	}
 	return &core.NexusExportComponents{Items: ec}
 }
+
+// exportComponents is converting components diff of sync config to export format. In source-nexus mode
+// assets are downloaded by their download urls from source repository instead of artifacts source
+func exportComponents(sc *config.SyncConfig, c []*core.NexusComponent) *core.NexusExportComponents {
+	if sc.SourceMode != config.SourceModeSourceNexus {
+		return genNexExpCompFromNexComp(sc.ArtifactsSource, c)
+	}
+	nec := genNexExpCompFromNexComp([]string{sourceRepositoryURL(sc)}, c)
+	for i, v := range c {
+		for ii, vv := range v.Assets {
+			nec.Items[i].Assets[ii].DownloadURL = sourceDownloadURL(sc, vv.DownloadURL)
+		}
+	}
+	return nec
+}
+
+// sourceRepositoryURL returns content address of sync config source repository
+func sourceRepositoryURL(sc *config.SyncConfig) string {
+	return fmt.Sprintf("%s/repository/%s/", strings.TrimSuffix(sc.SrcServerConfig.Server, "/"),
+		sc.SrcServerConfig.RepoName)
+}
+
+// sourceDownloadURL returns asset download url at configured source server. Base url of source nexus
+// may differ from server address which is used to access it, so only repository path of url is kept.
+// Url outside of source repository is returned as is to be rejected at upload
+func sourceDownloadURL(sc *config.SyncConfig, downloadURL string) string {
+	u, err := url.Parse(downloadURL)
+	if err != nil {
+		return downloadURL
+	}
+	repoPath := fmt.Sprintf("/repository/%s/", sc.SrcServerConfig.RepoName)
+	i := strings.Index(u.EscapedPath(), repoPath)
+	if i < 0 {
+		return downloadURL
+	}
+	return sourceRepositoryURL(sc) + u.EscapedPath()[i+len(repoPath):]
+}
+
+// artifactsSourceUpstreams returns credentials of sync config artifacts sources to be sent with components.
+// Credentials are set for primary source only, fallbacks get ones of their .npmrc scope if npmrc is used
+func artifactsSourceUpstreams(sc *config.SyncConfig) ([]*core.Upstream, error) {
+	if sc.SourceMode == config.SourceModeSourceNexus {
+		// Source repository is accessed with source server credentials
+		if sc.SrcServerConfig.User == "" {
+			return nil, nil
+		}
+		return []*core.Upstream{{URL: sourceRepositoryURL(sc), Auth: &core.UpstreamAuth{
+			Username: sc.SrcServerConfig.User,
+			Password: sc.SrcServerConfig.Pass,
+		}}}, nil
+	}
+
+	var upstreams []*core.Upstream
+	for i, v := range sc.ArtifactsSource {
+		sourceAuth := sc.ArtifactsSourceAuth
+		if i > 0 {
+			if sourceAuth.Npmrc == "" {
+				break
+			}
+			sourceAuth = config.UpstreamAuth{Npmrc: sourceAuth.Npmrc}
+		}
+		auth, err := core.NewUpstreamAuth(v, sourceAuth)
+		if err != nil {
+			if i > 0 {
+				// Fallback registry may be public one without credentials in .npmrc
+				continue
+			}
+			return nil, fmt.Errorf("artifactsSourceUpstreams: %w", err)
+		}
+		if auth != nil {
+			upstreams = append(upstreams, &core.Upstream{URL: v, Auth: auth})
+		}
+	}
+	return upstreams, nil
+}
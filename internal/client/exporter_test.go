@@ -1,6 +1,7 @@
 package client
 
 import (
+	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
 	"reflect"
 	"testing"
@@ -8,8 +9,8 @@ import (
 
 func Test_genNexExpCompFromNexComp(t *testing.T) {
 	type args struct {
-		artifactsSource string
-		c               []*core.NexusComponent
+		artifactsSources []string
+		c                []*core.NexusComponent
 	}
 	tests := []struct {
 		name string
@@ -18,7 +19,7 @@ func Test_genNexExpCompFromNexComp(t *testing.T) {
 	}{
 		{
 			name: "test1",
-			args: args{artifactsSource: "some_source", c: []*core.NexusComponent{
+			args: args{artifactsSources: []string{"some_source"}, c: []*core.NexusComponent{
 				{
 					ID:         "id1",
 					Repository: "repo1",
@@ -75,12 +76,104 @@ func Test_genNexExpCompFromNexComp(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "fallback sources",
+			args: args{artifactsSources: []string{"primary", "fallback1", "fallback2"}, c: []*core.NexusComponent{
+				{Repository: "repo1", Format: "maven2", Group: "group1", Name: "name1", Version: "1.0"},
+			}},
+			want: &core.NexusExportComponents{Items: []*core.NexusExportComponent{
+				{
+					Name:            "name1",
+					Version:         "1.0",
+					Repository:      "repo1",
+					Format:          "maven2",
+					Group:           "group1",
+					ArtifactsSource: "primary",
+					FallbackSources: []string{"fallback1", "fallback2"},
+				},
+			}},
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := genNexExpCompFromNexComp(tt.args.artifactsSource, tt.args.c); !reflect.DeepEqual(got, tt.want) {
+			if got := genNexExpCompFromNexComp(tt.args.artifactsSources, tt.args.c); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("genNexExpCompFromNexComp() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
+
+func Test_exportComponents(t *testing.T) {
+	c := []*core.NexusComponent{{
+		Repository: "npm-hosted",
+		Format:     "npm",
+		Name:       "pkg",
+		Version:    "1.0.0",
+		Assets: []*core.NexusComponentAsset{{
+			DownloadURL: "https://nexus.some/repository/npm-hosted/pkg/-/pkg-1.0.0.tgz",
+			Path:        "pkg/-/pkg-1.0.0.tgz",
+		}},
+	}}
+	tests := []struct {
+		name            string
+		sourceMode      string
+		sourceServer    string
+		wantSource      string
+		wantDownloadURL string
+	}{
+		{"upstream", config.SourceModeUpstream, "https://nexus.some/", "https://registry.npmjs.org/", ""},
+		{"source nexus", config.SourceModeSourceNexus, "https://nexus.some/", "https://nexus.some/repository/npm-hosted/",
+			"https://nexus.some/repository/npm-hosted/pkg/-/pkg-1.0.0.tgz"},
+		// Download url is built by nexus base url, which differs from address of source server
+		{"source nexus base url", config.SourceModeSourceNexus, "https://10.0.0.1:8081",
+			"https://10.0.0.1:8081/repository/npm-hosted/", "https://10.0.0.1:8081/repository/npm-hosted/pkg/-/pkg-1.0.0.tgz"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &config.SyncConfig{
+				Format:          "npm",
+				ArtifactsSource: config.ArtifactsSources{"https://registry.npmjs.org/"},
+				SourceMode:      tt.sourceMode,
+				SrcServerConfig: config.SrcServerConfig{Server: tt.sourceServer, RepoName: "npm-hosted"},
+			}
+			got := exportComponents(sc, c).Items[0]
+			if got.ArtifactsSource != tt.wantSource || got.Assets[0].DownloadURL != tt.wantDownloadURL {
+				t.Errorf("exportComponents() source = %s, download url = %s, want %s, %s",
+					got.ArtifactsSource, got.Assets[0].DownloadURL, tt.wantSource, tt.wantDownloadURL)
+			}
+		})
+	}
+}
+
+func Test_setArtifactsSourceUpstreams(t *testing.T) {
+	cfg := &config.Client{SyncConfigs: []*config.SyncConfig{{
+		Format:              "npm",
+		ArtifactsSource:     config.ArtifactsSources{"https://registry.npmjs.org/"},
+		ArtifactsSourceAuth: config.UpstreamAuth{User: "user1", Pass: "pass1"},
+		DstServerConfig:     config.DstServerConfig{Server: "https://nexus.some/", RepoName: "npm-hosted"},
+	}}}
+	tests := []struct {
+		name     string
+		repo     string
+		wantUser string
+		wantErr  bool
+	}{
+		{"matching sync config", "NPM-hosted", "user1", false},
+		{"no sync config", "npm-other", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diffs := []*core.OfflineDiff{{Server: "https://nexus.some/", Repository: tt.repo}}
+			err := setArtifactsSourceUpstreams(cfg, diffs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("setArtifactsSourceUpstreams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(diffs[0].Upstreams) != 1 || diffs[0].Upstreams[0].Auth.Username != tt.wantUser {
+				t.Errorf("setArtifactsSourceUpstreams() upstreams = %v, want user %s", diffs[0].Upstreams, tt.wantUser)
+			}
+		})
+	}
+}
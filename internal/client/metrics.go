@@ -26,6 +26,9 @@ type syncConfigMetrics struct {
 	lastSrcRepoAssetsCount *prometheus.GaugeVec
 	lastDstRepoAssetsCount *prometheus.GaugeVec
 	lastSyncDiffCount      *prometheus.GaugeVec
+	uploadAssetsCount      *prometheus.GaugeVec
+	uploadBytes            *prometheus.GaugeVec
+	uploadETA              *prometheus.GaugeVec
 }
 
 func NewMetrics(registry *prometheus.Registry) *nexusClientMetrics {
@@ -72,6 +75,24 @@ func NewMetrics(registry *prometheus.Registry) *nexusClientMetrics {
 				Name:      "sync_diff_total",
 				Help:      "Represents total count of sync differences between src and dst repos",
 			}, []string{labelSourceServer, labelSourceRepo, labelDestinationServer, labelDestinationRepo}),
+			uploadAssetsCount: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: clientName,
+				Subsystem: "upload",
+				Name:      "assets_total",
+				Help:      "Represents count of assets by status for running upload request on server",
+			}, []string{labelDestinationServer, labelDestinationRepo, labelStatus}),
+			uploadBytes: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: clientName,
+				Subsystem: "upload",
+				Name:      "transferred_bytes",
+				Help:      "Represents count of bytes transferred by running upload request on server",
+			}, []string{labelDestinationServer, labelDestinationRepo}),
+			uploadETA: promauto.With(registry).NewGaugeVec(prometheus.GaugeOpts{
+				Namespace: clientName,
+				Subsystem: "upload",
+				Name:      "eta_seconds",
+				Help:      "Represents estimated time to finish running upload request on server",
+			}, []string{labelDestinationServer, labelDestinationRepo}),
 		},
 	}
 }
@@ -137,6 +158,46 @@ func (ncm nexusClientMetrics) LastSyncDiffByLabels(srcServer, srcRepo, dstServer
 	return g
 }
 
+func (ncm nexusClientMetrics) UploadAssetsCountByLabels(server, repo, status string) prometheus.Gauge {
+	g, err := ncm.dynamicMetrics.uploadAssetsCount.GetMetricWith(prometheus.Labels{
+		labelDestinationServer: server,
+		labelDestinationRepo:   repo,
+		labelStatus:            status,
+	})
+	if err != nil {
+		log.Errorf("UploadAssetsCountByLabels: unable to set dynamic metric for destination repo %s: %v",
+			repo, err)
+		return nil
+	}
+	return g
+}
+
+func (ncm nexusClientMetrics) UploadBytesByLabels(server, repo string) prometheus.Gauge {
+	g, err := ncm.dynamicMetrics.uploadBytes.GetMetricWith(prometheus.Labels{
+		labelDestinationServer: server,
+		labelDestinationRepo:   repo,
+	})
+	if err != nil {
+		log.Errorf("UploadBytesByLabels: unable to set dynamic metric for destination repo %s: %v",
+			repo, err)
+		return nil
+	}
+	return g
+}
+
+func (ncm nexusClientMetrics) UploadETAByLabels(server, repo string) prometheus.Gauge {
+	g, err := ncm.dynamicMetrics.uploadETA.GetMetricWith(prometheus.Labels{
+		labelDestinationServer: server,
+		labelDestinationRepo:   repo,
+	})
+	if err != nil {
+		log.Errorf("UploadETAByLabels: unable to set dynamic metric for destination repo %s: %v",
+			repo, err)
+		return nil
+	}
+	return g
+}
+
 const (
 	labelDestinationServer = "destination_server"
 	labelDestinationRepo   = "destination_repo"
@@ -147,4 +208,5 @@ const (
 	labelVersion           = "version"
 	labelBuild             = "build"
 	labelSyncTimeMinutes   = "sync_time_minutes"
+	labelStatus            = "status"
 )
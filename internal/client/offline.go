@@ -0,0 +1,195 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"nexus-pusher/pkg/http_clients"
+	"nexus-pusher/pkg/utils"
+	"os"
+	"strings"
+)
+
+// SaveDiff compare repositories of all sync configs and write diff to file
+// to be exported with offline bundle later
+func (nc client) SaveDiff(fileName string) error {
+	var diffs []*core.OfflineDiff
+	for _, sc := range nc.config.SyncConfigs {
+		s1 := core.NewNexusServer(sc.SrcServerConfig.User, sc.SrcServerConfig.Pass,
+			sc.SrcServerConfig.Server, config.URIBase, config.URIComponents)
+		s2 := core.NewNexusServer(sc.DstServerConfig.User, sc.DstServerConfig.Pass,
+			sc.DstServerConfig.Server, config.URIBase, config.URIComponents)
+
+		// Check repos type
+		if err := checkOfflineRepoType(config.ComponentType(sc.Format)); err != nil {
+			return fmt.Errorf("SaveDiff: %w", err)
+		}
+		if err := doCheckRepoTypes(sc); err != nil {
+			return fmt.Errorf("SaveDiff: %w", err)
+		}
+
+		srcTLS, dstTLS, err := serversTLS(sc)
+		if err != nil {
+			return fmt.Errorf("SaveDiff: %w", err)
+		}
+
+		// Get repo diff
+		cmpDiff, err := nc.doCompareComponents(s1, http_clients.HttpRetryClientTLS(srcTLS), sc.SrcServerConfig.RepoName,
+			s2, http_clients.HttpRetryClientTLS(dstTLS), sc.DstServerConfig.RepoName)
+		if err != nil {
+			return fmt.Errorf("SaveDiff: %w", err)
+		}
+
+		log.Printf("Found %d differences between '%s' repo at server %s and '%s' repo at server %s",
+			len(cmpDiff),
+			sc.SrcServerConfig.RepoName,
+			sc.SrcServerConfig.Server,
+			sc.DstServerConfig.RepoName,
+			sc.DstServerConfig.Server)
+
+		if len(cmpDiff) == 0 {
+			continue
+		}
+
+		// Credentials are not saved. Destination ones are taken from config at import,
+		// artifacts source ones are taken from config at export
+		diffs = append(diffs, &core.OfflineDiff{
+			Server:     sc.DstServerConfig.Server,
+			Repository: sc.DstServerConfig.RepoName,
+			Items:      exportComponents(sc, cmpDiff).Items,
+		})
+	}
+
+	body, err := json.MarshalIndent(diffs, "", "  ")
+	if err != nil {
+		return fmt.Errorf("SaveDiff: %w", err)
+	}
+	if err := ioutil.WriteFile(fileName, body, 0600); err != nil {
+		return fmt.Errorf("SaveDiff: %w", err)
+	}
+	return nil
+}
+
+// checkOfflineRepoType check components of repository type can be exported to offline bundle
+func checkOfflineRepoType(repoType config.ComponentType) error {
+	switch repoType.Lower() {
+	case config.DOCKER, config.GO:
+		// Images are copied with registry API and go modules are cached through destination proxy
+		return &utils.ContextError{
+			Context: "checkOfflineRepoType",
+			Err:     fmt.Errorf("error: repository type '%s' is not supported for offline transfer", repoType),
+		}
+	}
+	return nil
+}
+
+// RunExport downloads all components from diff file to offline bundle.
+// Artifacts source credentials are taken from sync configs of client config if it's set
+func RunExport(diffFileName string, bundleFileName string, cfg *config.Client) error {
+	body, err := ioutil.ReadFile(diffFileName)
+	if err != nil {
+		return fmt.Errorf("RunExport: %w", err)
+	}
+
+	var diffs []*core.OfflineDiff
+	if err := json.Unmarshal(body, &diffs); err != nil {
+		return fmt.Errorf("RunExport: %w", err)
+	}
+
+	if cfg != nil {
+		if err := setArtifactsSourceUpstreams(cfg, diffs); err != nil {
+			return fmt.Errorf("RunExport: %w", err)
+		}
+	}
+
+	results, err := core.ExportBundle(context.Background(), diffs, bundleFileName)
+	if err != nil {
+		return fmt.Errorf("RunExport: %w", err)
+	}
+
+	logOfflineResults("Export", results)
+	return nil
+}
+
+// setArtifactsSourceUpstreams will set artifacts source credentials of matching sync config to every diff
+func setArtifactsSourceUpstreams(cfg *config.Client, diffs []*core.OfflineDiff) error {
+	for _, diff := range diffs {
+		sc := dstSyncConfig(cfg, diff.Server, diff.Repository)
+		if sc == nil {
+			return &utils.ContextError{
+				Context: "setArtifactsSourceUpstreams",
+				Err: fmt.Errorf("no sync config found for destination repo '%s' at server %s",
+					diff.Repository, diff.Server),
+			}
+		}
+		upstreams, err := artifactsSourceUpstreams(sc)
+		if err != nil {
+			return fmt.Errorf("setArtifactsSourceUpstreams: %w", err)
+		}
+		diff.Upstreams = upstreams
+	}
+	return nil
+}
+
+// RunImport verify offline bundle and upload its components to destination repositories
+func (nc client) RunImport(bundleFileName string) error {
+	dir, err := ioutil.TempDir("", "nexus-pusher-import")
+	if err != nil {
+		return fmt.Errorf("RunImport: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	manifest, err := core.ReadBundle(bundleFileName, dir)
+	if err != nil {
+		return fmt.Errorf("RunImport: %w", err)
+	}
+
+	for _, diff := range manifest.Diffs {
+		sc := dstSyncConfig(nc.config, diff.Server, diff.Repository)
+		if sc == nil {
+			return &utils.ContextError{
+				Context: "RunImport",
+				Err: fmt.Errorf("no sync config found for destination repo '%s' at server %s",
+					diff.Repository, diff.Server),
+			}
+		}
+
+		s := core.NewNexusServer(sc.DstServerConfig.User, sc.DstServerConfig.Pass,
+			sc.DstServerConfig.Server, config.URIBase, config.URIComponents)
+		if s.TLS, err = core.TLSConfig(sc.DstServerConfig.TLS); err != nil {
+			return fmt.Errorf("RunImport: %w", err)
+		}
+
+		logOfflineResults("Import", s.ImportBundle(context.Background(), manifest, diff, dir))
+	}
+	return nil
+}
+
+// dstSyncConfig search sync config by destination server and repository
+func dstSyncConfig(cfg *config.Client, server string, repo string) *config.SyncConfig {
+	for _, v := range cfg.SyncConfigs {
+		if strings.EqualFold(v.DstServerConfig.Server, server) && strings.EqualFold(v.DstServerConfig.RepoName, repo) {
+			return v
+		}
+	}
+	return nil
+}
+
+func logOfflineResults(operation string, results []core.UploadResult) {
+	var errorsCounter int
+	for _, v := range results {
+		if v.Err != nil {
+			errorsCounter++
+			log.Warnf("Asset processing error: %s asset=%s", v.Err.Error(), v.ComponentPath)
+		}
+	}
+	if errorsCounter != 0 {
+		log.Warnf("%s complete with %d errors of %d components", operation, errorsCounter, len(results))
+	} else {
+		log.Printf("%s successfully complete for %d components.", operation, len(results))
+	}
+}
@@ -2,8 +2,12 @@ package client
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/goccy/go-json"
+	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 	"io/ioutil"
 	"net/http"
@@ -21,28 +25,35 @@ type pushClient struct {
 	serverAddress string
 	serverUser    string
 	serverPass    string
-	cookie        *http.Cookie
-	metrics       *nexusClientMetrics
+	// tls is used to verify server and present client certificate, nil for defaults
+	tls     *tls.Config
+	cookie  *http.Cookie
+	metrics *nexusClientMetrics
 }
 
 func newPushClient(
 	serverAddress string,
 	serverUser string,
 	serverPass string,
+	tls *tls.Config,
 	metrics *nexusClientMetrics) *pushClient {
-	return &pushClient{serverAddress: serverAddress, serverUser: serverUser, serverPass: serverPass, metrics: metrics}
+	return &pushClient{serverAddress: serverAddress, serverUser: serverUser, serverPass: serverPass, tls: tls,
+		metrics: metrics}
 }
 
-// authorize the client with server using plain type credentials from configuration file
+// authorize the client with server using plain type credentials from configuration file.
+// Credentials are not sent if client is authenticated with certificate only
 func (p *pushClient) authorize() error {
 	requestUrl := fmt.Sprintf("%s%s%s", p.serverAddress, config.URIBase, config.URILogin)
-	client := http_clients.HttpRetryClient()
+	client := http_clients.HttpRetryClientTLS(p.tls)
 	req, err := http.NewRequest("GET", requestUrl, nil)
 	if err != nil {
 		return fmt.Errorf("authorize: %w", err)
 	}
 	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
-	req.SetBasicAuth(p.serverUser, p.serverPass)
+	if p.serverUser != "" {
+		req.SetBasicAuth(p.serverUser, p.serverPass)
+	}
 	// Send request
 	resp, err := client.Do(req)
 	if err != nil {
@@ -77,7 +88,7 @@ func (p *pushClient) refreshAuth() error {
 	if time.Until(p.cookie.Expires) < config.JWTTokenRefreshWindow*time.Second {
 		requestUrl := fmt.Sprintf("%s%s%s", p.serverAddress, config.URIBase, config.URIRefresh)
 		// Setup http client
-		client := http_clients.HttpRetryClient()
+		client := http_clients.HttpRetryClientTLS(p.tls)
 		// Make new request
 		req, err := http.NewRequest("GET", requestUrl, nil)
 		if err != nil {
@@ -125,7 +136,7 @@ func (p *pushClient) sendComparedRequest(data *core.NexusExportComponents, repoN
 		config.URIComponents,
 		repoName)
 	// Setup http client
-	client := http_clients.HttpRetryClient()
+	client := http_clients.HttpRetryClientTLS(p.tls)
 	// Encode data to buffer
 	err := json.NewEncoder(&buf).Encode(data)
 	if err != nil {
@@ -166,8 +177,9 @@ func (p *pushClient) sendComparedRequest(data *core.NexusExportComponents, repoN
 	return body, nil
 }
 
-// pollComparedResults long-http polling function to get upload results from server
-func (p *pushClient) pollComparedResults(body []byte, dstRepo string, dstServer string) error {
+// pollComparedResults waits for upload results from server. Job events stream is used if server supports it,
+// otherwise server is polled every second. Server request is canceled if ctx is done while waiting
+func (p *pushClient) pollComparedResults(ctx context.Context, body []byte, dstRepo string, dstServer string) error {
 	// Convert body to Message type
 	msg := &server.Message{}
 	if err := json.Unmarshal(body, msg); err != nil {
@@ -176,7 +188,59 @@ func (p *pushClient) pollComparedResults(body []byte, dstRepo string, dstServer
 
 	log.WithFields(
 		log.Fields{"id": msg.ID},
-	).Infof("Start polling results for destination repo '%s' at server '%s'", dstRepo, dstServer)
+	).Infof("Start waiting results for destination repo '%s' at server '%s'", dstRepo, dstServer)
+	result, err := p.streamJobEvents(ctx, msg, dstRepo, dstServer)
+	if errors.Is(err, errEventsNotSupported) {
+		log.WithFields(
+			log.Fields{"id": msg.ID},
+		).Debugf("Server %s doesn't support job events. Falling back to polling...", p.serverAddress)
+		result, err = p.pollJobMessage(ctx, msg, dstRepo, dstServer)
+	}
+	if ctx.Err() != nil {
+		log.WithFields(
+			log.Fields{"id": msg.ID},
+		).Warnf("Canceling upload request for destination repo '%s' at server '%s'", dstRepo, dstServer)
+		if err := p.cancelComparedRequest(msg.ID); err != nil {
+			return fmt.Errorf("pollComparedResults: %w", err)
+		}
+		return &utils.ContextError{
+			Context: "pollComparedResults",
+			Err:     fmt.Errorf("waiting for message id %s interrupted: %w", msg.ID, ctx.Err()),
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("pollComparedResults: %w", err)
+	}
+
+	log.WithFields(
+		log.Fields{
+			"id":     result.ID,
+			"errors": len(result.Response),
+		},
+	).Infof("Polling complete for destinantion repo '%s' at server '%s'",
+		dstRepo, dstServer)
+
+	// Update metric for last successfully sync time
+	if len(result.Response) > 0 {
+		p.metrics.LastSyncTimeByLabels(
+			dstServer,
+			dstRepo,
+			result.ID.String(),
+			strconv.FormatInt(int64(len(result.Response)), 10)).Set(float64(time.Now().Unix()))
+	}
+
+	// log all response errors
+	for _, m := range result.Response {
+		log.WithFields(
+			log.Fields{"id": result.ID},
+		).Warnf("%s", m)
+	}
+	return nil
+}
+
+// pollJobMessage long-http polling function to get complete job message from server
+func (p *pushClient) pollJobMessage(ctx context.Context, msg *server.Message, dstRepo string,
+	dstServer string) (*server.Message, error) {
 	// Queue http polling
 	requestUrl := fmt.Sprintf("%s%s%s?uuid=%s",
 		p.serverAddress,
@@ -185,15 +249,15 @@ func (p *pushClient) pollComparedResults(body []byte, dstRepo string, dstServer
 		msg.ID)
 
 	// Setup http client
-	client := http_clients.HttpRetryClient()
+	client := http_clients.HttpRetryClientTLS(p.tls)
 
 	// Poll maximum for 3600 seconds (60 min)
 	limitTime := 3600
 	for x := 1; x < limitTime; x++ {
-		// Setup new Request
-		req, err := http.NewRequest("GET", requestUrl, nil)
+		// Setup new Request, it's aborted when upload is interrupted
+		req, err := http.NewRequestWithContext(ctx, "GET", requestUrl, nil)
 		if err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 
 		// Set headers
@@ -205,13 +269,14 @@ func (p *pushClient) pollComparedResults(body []byte, dstRepo string, dstServer
 		// Send request
 		resp, err := client.Do(req)
 		if err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 
 		// Check server response
 		if resp.StatusCode != http.StatusOK {
-			return &utils.ContextError{
-				Context: "pollComparedResults",
+			resp.Body.Close()
+			return nil, &utils.ContextError{
+				Context: "pollJobMessage",
 				Err: fmt.Errorf("error: %s responded with status: %s",
 					p.serverAddress,
 					resp.Status),
@@ -221,62 +286,123 @@ func (p *pushClient) pollComparedResults(body []byte, dstRepo string, dstServer
 		// Read all body data
 		body, err := ioutil.ReadAll(resp.Body)
 		if err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 		// Close response body
 		if err := resp.Body.Close(); err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 
 		// Convert body to Message type
 		if err := json.Unmarshal(body, msg); err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 
 		// If server respond with 'complete' message stop polling
 		if msg.Complete {
-			log.WithFields(
-				log.Fields{
-					"id":     msg.ID,
-					"errors": len(msg.Response),
-				},
-			).Infof("Polling complete for destinantion repo '%s' at server '%s'",
-				dstRepo, dstServer)
-
-			// Update metric for last successfully sync time
-			if len(msg.Response) > 0 {
-				p.metrics.LastSyncTimeByLabels(
-					dstServer,
-					dstRepo,
-					msg.ID.String(),
-					strconv.FormatInt(int64(len(msg.Response)), 10)).Set(float64(time.Now().Unix()))
-			}
-
-			// log all response errors
-			for _, m := range msg.Response {
-				log.WithFields(
-					log.Fields{"id": msg.ID},
-				).Warnf("%s", m)
-			}
-			return nil
+			return msg, nil
 		}
-		// Report server polling status every 30 seconds
+		// Update upload progress metrics and report it every 30 seconds
+		p.updateProgressMetrics(msg.Progress, dstRepo, dstServer)
 		if x%30 == 0 {
-			log.WithFields(
-				log.Fields{"id": msg.ID}).Debugf("Server polling in progress... %d seconds passed", x)
+			logProgress(msg, dstRepo, dstServer)
 		}
 		// Try to refresh auth token
 		if err := p.refreshAuth(); err != nil {
-			return fmt.Errorf("pollComparedResults: %w", err)
+			return nil, fmt.Errorf("pollJobMessage: %w", err)
 		}
 		// Limit server requests to 1 RPS
-		time.Sleep(1 * time.Second)
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("pollJobMessage: %w", ctx.Err())
+		case <-time.After(1 * time.Second):
+		}
 	}
 	// Show error if we don't get results in time
-	return &utils.ContextError{
-		Context: "pollComparedResults",
+	return nil, &utils.ContextError{
+		Context: "pollJobMessage",
 		Err: fmt.Errorf("unable to get results from for message id %s in %d seconds",
 			msg.ID,
 			limitTime),
 	}
 }
+
+// cancelComparedRequest asks server to stop processing of sent diff
+func (p *pushClient) cancelComparedRequest(id uuid.UUID) error {
+	// Make sure JWT token is still valid
+	if err := p.refreshAuth(); err != nil {
+		return fmt.Errorf("cancelComparedRequest: %w", err)
+	}
+
+	requestUrl := fmt.Sprintf("%s%s%s?uuid=%s",
+		p.serverAddress,
+		config.URIBase,
+		config.URIComponents,
+		id)
+	// Upload context is already canceled, so cancel request is sent with a fresh one
+	req, err := http.NewRequestWithContext(context.Background(), "DELETE", requestUrl, nil)
+	if err != nil {
+		return fmt.Errorf("cancelComparedRequest: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	// Append JWT auth Cookie
+	req.AddCookie(p.cookie)
+
+	// Send request
+	resp, err := http_clients.HttpRetryClientTLS(p.tls).Do(req)
+	if err != nil {
+		return fmt.Errorf("cancelComparedRequest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check server response
+	if resp.StatusCode != http.StatusOK {
+		return &utils.ContextError{
+			Context: "cancelComparedRequest",
+			Err:     fmt.Errorf("error: %s responded with status: %s", p.serverAddress, resp.Status),
+		}
+	}
+	log.WithFields(log.Fields{"id": id}).Infof("Upload request successfully canceled at %s", p.serverAddress)
+	return nil
+}
+
+// updateProgressMetrics will export server upload progress as metrics
+func (p *pushClient) updateProgressMetrics(progress server.Progress, dstRepo string, dstServer string) {
+	for status, count := range map[string]int{
+		"total":     progress.Total,
+		"succeeded": progress.Succeeded,
+		"failed":    progress.Failed,
+		"in_flight": progress.InFlight,
+	} {
+		if g := p.metrics.UploadAssetsCountByLabels(dstServer, dstRepo, status); g != nil {
+			g.Set(float64(count))
+		}
+	}
+	if g := p.metrics.UploadBytesByLabels(dstServer, dstRepo); g != nil {
+		g.Set(float64(progress.Bytes))
+	}
+	if g := p.metrics.UploadETAByLabels(dstServer, dstRepo); g != nil {
+		g.Set(float64(progress.ETASeconds))
+	}
+}
+
+// logProgress will log server upload progress
+func logProgress(msg *server.Message, dstRepo string, dstServer string) {
+	progress := msg.Progress
+	fields := log.Fields{
+		"id":        msg.ID,
+		"state":     msg.State,
+		"total":     progress.Total,
+		"succeeded": progress.Succeeded,
+		"failed":    progress.Failed,
+		"in_flight": progress.InFlight,
+		"bytes":     progress.Bytes,
+	}
+	if progress.ETASeconds > 0 {
+		fields["eta"] = (time.Duration(progress.ETASeconds) * time.Second).String()
+	}
+	log.WithFields(fields).Infof("Upload in progress for destination repo '%s' at server '%s'", dstRepo, dstServer)
+	for _, v := range progress.Current {
+		log.WithFields(log.Fields{"id": msg.ID}).Debugf("Processing: %s", v)
+	}
+}
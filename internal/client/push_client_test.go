@@ -0,0 +1,47 @@
+package client
+
+import (
+	"context"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/server"
+	"testing"
+	"time"
+)
+
+func Test_pushClient_pollComparedResults_canceled(t *testing.T) {
+	// Server is hanging until poll request is aborted
+	canceled := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "DELETE" {
+			canceled <- struct{}{}
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	p := newPushClient(srv.URL, "user", "pass", nil, NewMetrics(prometheus.NewRegistry()))
+	p.cookie = &http.Cookie{Name: config.JWTCookieName, Expires: time.Now().Add(time.Hour)}
+	body, err := json.Marshal(&server.Message{ID: uuid.New()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	started := time.Now()
+	err = p.pollComparedResults(ctx, body, "repo1", "https://nexus.some")
+	if err == nil || time.Since(started) > 5*time.Second {
+		t.Errorf("pollComparedResults() error = %v in %v, want request aborted by context", err, time.Since(started))
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Errorf("pollComparedResults() didn't cancel upload request on server")
+	}
+}
@@ -6,6 +6,12 @@ import (
 
 type Args struct {
 	ConfigPath string
+	// ConfigSet is true if config path is set explicitly
+	ConfigSet bool
+	SaveDiff  string
+	Diff      string
+	Export    string
+	Import    string
 }
 
 // GetConfigArgs returns config specific args
@@ -14,6 +20,15 @@ func (a *Args) GetConfigArgs() *Args {
 
 	pflag.StringVarP(&a.ConfigPath, "config", "c", configName,
 		"Config file path")
+	pflag.StringVar(&a.SaveDiff, "save-diff", "",
+		"Save components diff of all client sync configs to file instead of sending it to server")
+	pflag.StringVar(&a.Diff, "diff", "",
+		"Components diff file path to be exported (used with '--export')")
+	pflag.StringVar(&a.Export, "export", "",
+		"Download all components from '--diff' file and write them to offline bundle file. "+
+			"Artifacts source credentials are taken from client config if '--config' is set")
+	pflag.StringVar(&a.Import, "import", "",
+		"Upload all components from offline bundle file to destination repositories of client sync configs")
 	pflag.BoolVarP(&showHelp, "help", "h", false,
 		"Show help message")
 
@@ -22,6 +37,7 @@ func (a *Args) GetConfigArgs() *Args {
 		pflag.Usage()
 		return nil
 	}
+	a.ConfigSet = pflag.CommandLine.Changed("config")
 
 	return a
 }
@@ -1,5 +1,10 @@
 package config
 
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+)
+
 // Client is defines client-side config part
 type Client struct {
 	Daemon struct {
@@ -13,17 +18,33 @@ type Client struct {
 	} `yaml:"metrics"`
 	Server         string         `yaml:"server"`
 	ServerAuth     ServerAuth     `yaml:"serverAuth"`
+	ServerTLS      TLSClient      `yaml:"serverTls"`
 	SyncGlobalAuth SyncGlobalAuth `yaml:"syncGlobalAuth"`
 	SyncConfigs    []*SyncConfig  `yaml:"syncConfigs"`
 }
 
 type SyncGlobalAuth struct {
-	SrcServer     string `yaml:"srcServer"`
-	SrcServerUser string `yaml:"srcServerUser"`
-	SrcServerPass string `yaml:"srcServerPass"`
-	DstServer     string `yaml:"dstServer"`
-	DstServerUser string `yaml:"dstServerUser"`
-	DstServerPass string `yaml:"dstServerPass"`
+	SrcServer     string    `yaml:"srcServer"`
+	SrcServerUser string    `yaml:"srcServerUser"`
+	SrcServerPass string    `yaml:"srcServerPass"`
+	SrcServerTLS  TLSClient `yaml:"srcServerTls"`
+	DstServer     string    `yaml:"dstServer"`
+	DstServerUser string    `yaml:"dstServerUser"`
+	DstServerPass string    `yaml:"dstServerPass"`
+	DstServerTLS  TLSClient `yaml:"dstServerTls"`
+}
+
+// TLSClient is defines TLS options of client connection
+type TLSClient struct {
+	// CAPath is a CA bundle trusted in addition to system roots
+	CAPath string `yaml:"caPath"`
+	// CertPath and KeyPath is a client certificate presented to server
+	CertPath string `yaml:"certPath"`
+	KeyPath  string `yaml:"keyPath"`
+	// InsecureSkipVerify disables server certificate verification. Don't use it in production
+	InsecureSkipVerify bool `yaml:"insecureSkipVerify"`
+	// ServerName overrides server name used for SNI and certificate verification
+	ServerName string `yaml:"serverName"`
 }
 
 // ServerAuth is defines client side server auth
@@ -34,11 +55,66 @@ type ServerAuth struct {
 
 // SyncConfig is defines set of sync-configs for client
 type SyncConfig struct {
-	Format          string          `yaml:"format"`
-	ArtifactsSource string          `yaml:"artifactsSource"`
-	SrcServerConfig SrcServerConfig `yaml:"srcServerConfig"`
-	DstServerConfig DstServerConfig `yaml:"dstServerConfig"`
+	Format          string           `yaml:"format"`
+	ArtifactsSource ArtifactsSources `yaml:"artifactsSource"`
+	SrcServerConfig SrcServerConfig  `yaml:"srcServerConfig"`
+	DstServerConfig DstServerConfig  `yaml:"dstServerConfig"`
+	Mirror          Mirror           `yaml:"mirror"`
 	IsProcessing    bool
+
+	// ArtifactsSourceAuth is defines credentials of artifacts source, they are sent to nexus-pusher server
+	ArtifactsSourceAuth UpstreamAuth `yaml:"artifactsSourceAuth"`
+	// SourceMode is defines where artifacts are downloaded from, artifacts source or source nexus repository
+	SourceMode string `yaml:"sourceMode"`
+}
+
+// ArtifactsSources is an ordered list of artifacts sources, the first one is primary and others are
+// fallbacks tried in order. It may be set as a single url or as a list of urls
+type ArtifactsSources []string
+
+// UnmarshalYAML will decode artifacts sources from a single url or a list of urls
+func (a *ArtifactsSources) UnmarshalYAML(value *yaml.Node) error {
+	if value.Kind == yaml.ScalarNode {
+		var source string
+		if err := value.Decode(&source); err != nil {
+			return fmt.Errorf("UnmarshalYAML: %w", err)
+		}
+		*a = nil
+		if source != "" {
+			*a = ArtifactsSources{source}
+		}
+		return nil
+	}
+	var sources []string
+	if err := value.Decode(&sources); err != nil {
+		return fmt.Errorf("UnmarshalYAML: %w", err)
+	}
+	*a = sources
+	return nil
+}
+
+// UpstreamAuth is defines credentials of artifacts source. Only one of user/pass, token or npmrc may be set
+type UpstreamAuth struct {
+	User string `yaml:"user"`
+	Pass string `yaml:"pass"`
+	// Token is sent as a bearer token
+	Token string `yaml:"token"`
+	// Headers is a custom headers sent with every request, i.e. 'X-JFrog-Art-Api'
+	Headers map[string]string `yaml:"headers"`
+	// Npmrc is a path to .npmrc file which registry auth token (or user/password) is taken from
+	Npmrc string `yaml:"npmrc"`
+}
+
+// IsZero check if no credentials are set
+func (a UpstreamAuth) IsZero() bool {
+	return a.User == "" && a.Pass == "" && a.Token == "" && a.Npmrc == "" && len(a.Headers) == 0
+}
+
+// Mirror is defines deletion of destination components which are missing in source
+type Mirror struct {
+	Enabled      bool `yaml:"enabled"`
+	DryRun       bool `yaml:"dryRun"`
+	MaxDeletions int  `yaml:"maxDeletions"`
 }
 
 func (sc *SyncConfig) Lock() {
@@ -59,12 +135,19 @@ type SrcServerConfig struct {
 	User     string `yaml:"user"`
 	Pass     string `yaml:"pass"`
 	RepoName string `yaml:"repoName"`
+	// TLS is defines connection options of source server
+	TLS TLSClient `yaml:"tls"`
 }
 
 // DstServerConfig is defines destination server config (target)
 type DstServerConfig struct {
-	Server   string `yaml:"server"`
-	User     string `yaml:"user"`
-	Pass     string `yaml:"pass"`
-	RepoName string `yaml:"repoName"`
+	Server          string `yaml:"server"`
+	User            string `yaml:"user"`
+	Pass            string `yaml:"pass"`
+	RepoName        string `yaml:"repoName"`
+	DockerConnector string `yaml:"dockerConnector"`
+	// Profile is a name of nexus-pusher server destination profile used instead of sending credentials
+	Profile string `yaml:"profile"`
+	// TLS is defines connection options of destination server
+	TLS TLSClient `yaml:"tls"`
 }
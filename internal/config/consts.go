@@ -7,6 +7,12 @@ const (
 	serverPort string = "8181"
 	// Set default server bind address
 	serverBindAddress string = "0.0.0.0"
+	// Set default time to keep finished server jobs
+	serverJobsTTLMinutes int = 1440
+	// Set default count of failed server logins before client is blocked
+	serverMaxFailedLogins int = 5
+	// Set default time to block client after failed server logins
+	serverLockoutMinutes int = 15
 	// Set default config file name
 	configName string = "config.yaml"
 	// TimeZone Set default timezone
@@ -17,6 +23,24 @@ const (
 	clientMetricsEndpointURI = "/metrics"
 	// Set default client prometheus metrics endpoint port
 	clientMetricsEndpointPort = "9090"
+	// Set default maximum number of components deleted per sync in mirror mode
+	clientMirrorMaxDeletions = 100
+)
+
+const (
+	// TLSClientAuthNone Don't request client certificate
+	TLSClientAuthNone string = "none"
+	// TLSClientAuthOptional Verify client certificate if it's given
+	TLSClientAuthOptional string = "optional"
+	// TLSClientAuthRequire Require and verify client certificate
+	TLSClientAuthRequire string = "require"
+)
+
+const (
+	// SourceModeUpstream Download artifacts from artifacts source
+	SourceModeUpstream string = "upstream"
+	// SourceModeSourceNexus Download artifacts from source nexus repository
+	SourceModeSourceNexus string = "source-nexus"
 )
 
 const (
@@ -34,6 +58,10 @@ const (
 	URIComponents string = "/v1/components"
 	// URIRepositories Set repositories REST URI
 	URIRepositories string = "/v1/repositories"
+	// URIJobs Set jobs REST URI
+	URIJobs string = "/v1/jobs"
+	// URIEvents Set job events REST URI (follows job id)
+	URIEvents string = "/events"
 )
 
 const (
@@ -50,6 +78,11 @@ const (
 	pypiSrv   string = "https://pypi.org/"
 	maven2Srv string = "https://repo1.maven.org/maven2/"
 	nugetSrv  string = "https://api.nuget.org/v3/index.json"
+	dockerSrv string = "https://registry-1.docker.io"
+	rubySrv   string = "https://rubygems.org/"
+	aptSrv    string = "http://deb.debian.org/debian/"
+	goSrv     string = "https://proxy.golang.org/"
+	condaSrv  string = "https://conda.anaconda.org/conda-forge/"
 )
 
 // LogTimeFormat will format logrus time to specified format
@@ -12,5 +12,71 @@ type Server struct {
 		DomainName string `yaml:"domainName"`
 		KeyPath    string `yaml:"keyPath"`
 		CertPath   string `yaml:"certPath"`
+		// ClientCAPath is a CA bundle to verify client certificates
+		ClientCAPath string `yaml:"clientCaPath"`
+		// ClientAuth is one of 'none', 'optional' or 'require'
+		ClientAuth string `yaml:"clientAuth"`
 	} `yaml:"tls"`
+	Jobs struct {
+		StorePath  string `yaml:"storePath"`
+		TTLMinutes int    `yaml:"ttlMinutes"`
+	} `yaml:"jobs"`
+	Auth struct {
+		HtpasswdPath    string `yaml:"htpasswdPath"`
+		MaxFailedLogins int    `yaml:"maxFailedLogins"`
+		LockoutMinutes  int    `yaml:"lockoutMinutes"`
+	} `yaml:"auth"`
+	Destinations Destinations `yaml:"destinations"`
+	JWT          struct {
+		// SigningKey is an id of key used to sign new tokens
+		SigningKey string   `yaml:"signingKey"`
+		Keys       []JWTKey `yaml:"keys"`
+	} `yaml:"jwt"`
+	// Authorization is holding upload permissions by username. Any user may upload anywhere if it's empty
+	Authorization map[string]UserPermissions `yaml:"authorization"`
+	// Upstreams is holding connection options of artifacts sources
+	Upstreams []Upstream `yaml:"upstreams"`
+}
+
+// Upstream is defines connection options and credentials of artifacts sources which url starts with URL
+type Upstream struct {
+	URL  string       `yaml:"url"`
+	TLS  TLSClient    `yaml:"tls"`
+	Auth UpstreamAuth `yaml:"auth"`
+}
+
+// JWTKey is defines key used to sign or verify JWT tokens
+type JWTKey struct {
+	ID string `yaml:"id"`
+	// Algorithm is one of 'HS256', 'RS256' or 'ES256'
+	Algorithm string `yaml:"algorithm"`
+	// Path is a file with HMAC secret or PEM encoded private (or public for verification only) key
+	Path string `yaml:"path"`
+}
+
+// UserPermissions is defines destinations where user may upload to. Empty list allows anything
+type UserPermissions struct {
+	Hosts []string `yaml:"hosts"`
+	// Repositories is a list of repository name glob patterns, i.e. 'npm-*'
+	Repositories []string `yaml:"repositories"`
+	Formats      []string `yaml:"formats"`
+	// Admin allows user to see jobs of all users, others see their own jobs only
+	Admin bool `yaml:"admin"`
+}
+
+// Destinations is defines nexus servers allowed as upload destinations
+type Destinations struct {
+	// AllowedHosts is a list of hosts which client may upload to (hosts of profiles are allowed too)
+	AllowedHosts []string `yaml:"allowedHosts"`
+	// Profiles is holding destination credentials by profile name, so clients don't send them
+	Profiles map[string]DestinationProfile `yaml:"profiles"`
+}
+
+// DestinationProfile is defines destination nexus server with its credentials
+type DestinationProfile struct {
+	Host            string    `yaml:"host"`
+	User            string    `yaml:"user"`
+	Pass            string    `yaml:"pass"`
+	DockerConnector string    `yaml:"dockerConnector"`
+	TLS             TLSClient `yaml:"tls"`
 }
@@ -1,6 +1,7 @@
 package config
 
 import (
+	"context"
 	"io"
 	"net/http"
 	"strings"
@@ -21,6 +22,7 @@ func (c ComponentType) String() string {
 
 // Bundled check if ComponentType must be processed as bundle of assets
 // For example, maven2 requires to upload pom with assets simultaneously
+// and raw assets from the same directory are uploaded with one request
 func (c ComponentType) Bundled() bool {
 	switch c.Lower() {
 	case NPM:
@@ -31,6 +33,22 @@ func (c ComponentType) Bundled() bool {
 		return true
 	case NUGET:
 		return false
+	case HELM:
+		return false
+	case DOCKER:
+		return false
+	case RUBY:
+		return false
+	case APT:
+		return false
+	case YUM:
+		return false
+	case RAW:
+		return true
+	case GO:
+		return true
+	case CONDA:
+		return false
 	default:
 		// Return false by default is safe here because we already
 		// check component type in the previous code logic
@@ -51,18 +69,37 @@ const (
 	// NUGET Set NUGET specific variables
 	NUGET ComponentType = "nuget"
 
-	// HELM   ComponentType = "helm"
-	// DOCKER ComponentType = "docker"
-	// RUBY   ComponentType = "rubygems"
-	// APT    ComponentType = "apt"
+	// HELM Set HELM specific variables
+	HELM ComponentType = "helm"
+
+	// DOCKER Set DOCKER specific variables
+	DOCKER ComponentType = "docker"
+
+	// RUBY Set RUBYGEMS specific variables
+	RUBY ComponentType = "rubygems"
+
+	// APT Set APT specific variables
+	APT ComponentType = "apt"
+
+	// YUM Set YUM specific variables
+	YUM ComponentType = "yum"
+
+	// RAW Set RAW specific variables
+	RAW ComponentType = "raw"
+
+	// GO Set GO specific variables
+	GO ComponentType = "go"
+
+	// CONDA Set CONDA specific variables
+	CONDA ComponentType = "conda"
 )
 
 type Asseter interface {
-	DownloadAsset() (*http.Response, error)
+	DownloadAsset(ctx context.Context) (*http.Response, error)
 	PrepareAssetToUpload(io.Reader) (string, io.Reader)
 }
 
 type Componenter interface {
-	DownloadComponent() ([]*http.Response, error)
+	DownloadComponent(ctx context.Context) ([]*http.Response, error)
 	PrepareComponentToUpload([]*http.Response) (string, io.Reader)
 }
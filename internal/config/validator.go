@@ -2,7 +2,9 @@ package config
 
 import (
 	"fmt"
+	log "github.com/sirupsen/logrus"
 	"nexus-pusher/pkg/utils"
+	"path"
 )
 
 // ValidateConfig is used to validate config file for correct parameters
@@ -31,17 +33,74 @@ func (c *NexusConfig) validateServerConfig() error {
 			c.Server.BindAddress = serverBindAddress
 		}
 
-		if len(c.Server.Credentials) == 0 {
+		if len(c.Server.Credentials) == 0 && c.Server.Auth.HtpasswdPath == "" {
 			return &utils.ContextError{
 				Context: "validateServerConfig",
-				Err:     fmt.Errorf("server required 'credentials' variable is missing in %s", c.string),
+				Err: fmt.Errorf("server required 'credentials' or 'auth.htpasswdPath' variable is missing in %s",
+					c.string),
 			}
 		}
 
+		if c.Server.Auth.MaxFailedLogins == 0 {
+			c.Server.Auth.MaxFailedLogins = serverMaxFailedLogins
+		}
+
+		if c.Server.Auth.LockoutMinutes == 0 {
+			c.Server.Auth.LockoutMinutes = serverLockoutMinutes
+		}
+
 		if c.Server.Concurrency == 0 {
 			c.Server.Concurrency = clientConcurrency
 		}
 
+		if c.Server.Jobs.TTLMinutes == 0 {
+			c.Server.Jobs.TTLMinutes = serverJobsTTLMinutes
+		}
+
+		if err := c.validateJWTKeys(); err != nil {
+			return fmt.Errorf("validateServerConfig: %w", err)
+		}
+
+		for k, v := range c.Server.Authorization {
+			for _, pattern := range v.Repositories {
+				if _, err := path.Match(pattern, ""); err != nil {
+					return &utils.ContextError{
+						Context: "validateServerConfig",
+						Err: fmt.Errorf("wrong repository pattern '%s' of user '%s' authorization in %s: %v",
+							pattern, k, c.string, err),
+					}
+				}
+			}
+		}
+
+		for k, v := range c.Server.Destinations.Profiles {
+			if v.Host == "" || v.User == "" || v.Pass == "" {
+				return &utils.ContextError{
+					Context: "validateServerConfig",
+					Err: fmt.Errorf("server destination profile '%s' requires 'host', 'user' and 'pass' variables in %s",
+						k, c.string),
+				}
+			}
+			if err := c.validateTLSClient(fmt.Sprintf("destinations.profiles.%s.tls", k), v.TLS); err != nil {
+				return fmt.Errorf("validateServerConfig: %w", err)
+			}
+		}
+
+		for i, v := range c.Server.Upstreams {
+			if v.URL == "" {
+				return &utils.ContextError{
+					Context: "validateServerConfig",
+					Err:     fmt.Errorf("server upstream #%d requires 'url' variable in %s", i, c.string),
+				}
+			}
+			if err := c.validateTLSClient(fmt.Sprintf("upstreams.%s.tls", v.URL), v.TLS); err != nil {
+				return fmt.Errorf("validateServerConfig: %w", err)
+			}
+			if err := c.validateUpstreamAuth(fmt.Sprintf("upstreams.%s.auth", v.URL), v.Auth); err != nil {
+				return fmt.Errorf("validateServerConfig: %w", err)
+			}
+		}
+
 		if c.Server.TLS.Enabled && c.Server.TLS.Auto {
 			if c.Server.TLS.DomainName == "" {
 				return &utils.ContextError{
@@ -51,6 +110,26 @@ func (c *NexusConfig) validateServerConfig() error {
 			}
 		}
 
+		switch c.Server.TLS.ClientAuth {
+		case "":
+			c.Server.TLS.ClientAuth = TLSClientAuthNone
+		case TLSClientAuthNone:
+		case TLSClientAuthOptional, TLSClientAuthRequire:
+			if !c.Server.TLS.Enabled || c.Server.TLS.ClientCAPath == "" {
+				return &utils.ContextError{
+					Context: "validateServerConfig",
+					Err: fmt.Errorf("server 'tls.clientAuth' requires TLS to be enabled and 'tls.clientCaPath' to be set in %s",
+						c.string),
+				}
+			}
+		default:
+			return &utils.ContextError{
+				Context: "validateServerConfig",
+				Err: fmt.Errorf("server 'tls.clientAuth' must be one of '%s', '%s' or '%s' in %s",
+					TLSClientAuthNone, TLSClientAuthOptional, TLSClientAuthRequire, c.string),
+			}
+		}
+
 		if c.Server.TLS.Enabled && !c.Server.TLS.Auto {
 			if c.Server.TLS.KeyPath == "" || c.Server.TLS.CertPath == "" {
 				return &utils.ContextError{
@@ -63,17 +142,64 @@ func (c *NexusConfig) validateServerConfig() error {
 	return nil
 }
 
+func (c *NexusConfig) validateJWTKeys() error {
+	jwtCfg := &c.Server.JWT
+	if len(jwtCfg.Keys) == 0 {
+		return nil
+	}
+	ids := make(map[string]struct{})
+	for i, v := range jwtCfg.Keys {
+		if v.ID == "" || v.Path == "" {
+			return &utils.ContextError{
+				Context: "validateJWTKeys",
+				Err:     fmt.Errorf("server jwt key #%d requires 'id' and 'path' variables in %s", i, c.string),
+			}
+		}
+		if _, ok := ids[v.ID]; ok {
+			return &utils.ContextError{
+				Context: "validateJWTKeys",
+				Err:     fmt.Errorf("server jwt key id '%s' is duplicated in %s", v.ID, c.string),
+			}
+		}
+		ids[v.ID] = struct{}{}
+		switch v.Algorithm {
+		case "HS256", "RS256", "ES256":
+		default:
+			return &utils.ContextError{
+				Context: "validateJWTKeys",
+				Err: fmt.Errorf("server jwt key '%s' algorithm must be one of 'HS256', 'RS256' or 'ES256' in %s",
+					v.ID, c.string),
+			}
+		}
+	}
+	// Single key is used to sign tokens by default
+	if jwtCfg.SigningKey == "" && len(jwtCfg.Keys) == 1 {
+		jwtCfg.SigningKey = jwtCfg.Keys[0].ID
+	}
+	if _, ok := ids[jwtCfg.SigningKey]; !ok {
+		return &utils.ContextError{
+			Context: "validateJWTKeys",
+			Err:     fmt.Errorf("server 'jwt.signingKey' must be set to one of jwt keys ids in %s", c.string),
+		}
+	}
+	return nil
+}
+
 func (c *NexusConfig) validateClientConfig() error {
 	if c.Client != nil {
-		// Check client required parameters
-		if c.Client.ServerAuth.User == "" {
+		// Check client required parameters. Password is not needed if client is authenticated with certificate
+		if err := c.validateTLSClient("serverTls", c.Client.ServerTLS); err != nil {
+			return fmt.Errorf("validateClientConfig: %w", err)
+		}
+
+		if c.Client.ServerAuth.User == "" && c.Client.ServerTLS.CertPath == "" {
 			return &utils.ContextError{
 				Context: "validateClientConfig",
 				Err:     fmt.Errorf("client required 'serverAuth.user' variable is missing in %s", c.string),
 			}
 		}
 
-		if c.Client.ServerAuth.Pass == "" {
+		if c.Client.ServerAuth.Pass == "" && c.Client.ServerTLS.CertPath == "" {
 			return &utils.ContextError{
 				Context: "validateClientConfig",
 				Err:     fmt.Errorf("client required 'serverAuth.pass' variable is missing in %s", c.string),
@@ -116,6 +242,21 @@ func (c *NexusConfig) validateClientConfig() error {
 				if err := c.validateTargetServerConfigs(v, i); err != nil {
 					return fmt.Errorf("validateClientConfig: %w", err)
 				}
+				if err := c.validateUpstreamAuth(fmt.Sprintf("syncConfigs[%d].artifactsSourceAuth", i),
+					v.ArtifactsSourceAuth); err != nil {
+					return fmt.Errorf("validateClientConfig: %w", err)
+				}
+				// Set default mirror deletions limit
+				if v.Mirror.MaxDeletions == 0 {
+					v.Mirror.MaxDeletions = clientMirrorMaxDeletions
+				}
+				if v.Mirror.MaxDeletions < 0 {
+					return &utils.ContextError{
+						Context: "validateClientConfig",
+						Err: fmt.Errorf("client 'mirror.maxDeletions' for syncConfig #%d must be positive in %s",
+							i, c.string),
+					}
+				}
 			}
 		}
 	}
@@ -123,6 +264,24 @@ func (c *NexusConfig) validateClientConfig() error {
 }
 
 func (c *NexusConfig) validateArtifactsSource(syncConfig *SyncConfig, index int) error {
+	switch syncConfig.SourceMode {
+	case "":
+		syncConfig.SourceMode = SourceModeUpstream
+	case SourceModeUpstream:
+	case SourceModeSourceNexus:
+		// Artifacts are downloaded from source repository, so there is no artifacts source
+		if err := validateSourceNexusMode(syncConfig); err != nil {
+			return fmt.Errorf("validateArtifactsSource: %w", err)
+		}
+		return nil
+	default:
+		return &utils.ContextError{
+			Context: "validateArtifactsSource",
+			Err: fmt.Errorf("syncconfig 'sourceMode' must be one of '%s', '%s' in %v",
+				SourceModeUpstream, SourceModeSourceNexus, syncConfig),
+		}
+	}
+
 	switch syncConfig.Format {
 	case "":
 		return &utils.ContextError{
@@ -130,20 +289,83 @@ func (c *NexusConfig) validateArtifactsSource(syncConfig *SyncConfig, index int)
 			Err:     fmt.Errorf("syncconfig required 'format' variable is missing in %v", syncConfig),
 		}
 	case MAVEN2.String():
-		if syncConfig.ArtifactsSource == "" {
-			c.Client.SyncConfigs[index].ArtifactsSource = maven2Srv
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{maven2Srv}
 		}
 	case PYPI.String():
-		if syncConfig.ArtifactsSource == "" {
-			c.Client.SyncConfigs[index].ArtifactsSource = pypiSrv
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{pypiSrv}
 		}
 	case NPM.String():
-		if syncConfig.ArtifactsSource == "" {
-			c.Client.SyncConfigs[index].ArtifactsSource = npmSrv
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{npmSrv}
 		}
 	case NUGET.String():
-		if syncConfig.ArtifactsSource == "" {
-			c.Client.SyncConfigs[index].ArtifactsSource = nugetSrv
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{nugetSrv}
+		}
+	case DOCKER.String():
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{dockerSrv}
+		}
+	case RUBY.String():
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{rubySrv}
+		}
+	case APT.String():
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{aptSrv}
+		}
+	case GO.String():
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{goSrv}
+		}
+	case CONDA.String():
+		if len(syncConfig.ArtifactsSource) == 0 {
+			c.Client.SyncConfigs[index].ArtifactsSource = ArtifactsSources{condaSrv}
+		}
+	case HELM.String(), YUM.String(), RAW.String():
+		// There is no single well-known public repository, so it must be set explicitly
+		if len(syncConfig.ArtifactsSource) == 0 {
+			return &utils.ContextError{
+				Context: "validateArtifactsSource",
+				Err: fmt.Errorf("syncconfig required 'artifactsSource' variable is missing for '%s' format in %v",
+					syncConfig.Format, syncConfig),
+			}
+		}
+	}
+	for _, v := range syncConfig.ArtifactsSource {
+		if v == "" {
+			return &utils.ContextError{
+				Context: "validateArtifactsSource",
+				Err:     fmt.Errorf("syncconfig 'artifactsSource' list must not contain empty urls in %v", syncConfig),
+			}
+		}
+	}
+	return nil
+}
+
+// validateSourceNexusMode check sync config is able to download artifacts from source nexus repository
+func validateSourceNexusMode(syncConfig *SyncConfig) error {
+	switch syncConfig.Format {
+	case "":
+		return &utils.ContextError{
+			Context: "validateSourceNexusMode",
+			Err:     fmt.Errorf("syncconfig required 'format' variable is missing in %v", syncConfig),
+		}
+	case DOCKER.String(), GO.String():
+		// Images are copied with registry API and go modules are cached through destination proxy
+		return &utils.ContextError{
+			Context: "validateSourceNexusMode",
+			Err: fmt.Errorf("syncconfig 'sourceMode' '%s' is not supported for '%s' format in %v",
+				SourceModeSourceNexus, syncConfig.Format, syncConfig),
+		}
+	}
+	if len(syncConfig.ArtifactsSource) != 0 || !syncConfig.ArtifactsSourceAuth.IsZero() {
+		return &utils.ContextError{
+			Context: "validateSourceNexusMode",
+			Err: fmt.Errorf("syncconfig 'artifactsSource' and 'artifactsSourceAuth' can't be set with 'sourceMode' '%s' in %v",
+				SourceModeSourceNexus, syncConfig),
 		}
 	}
 	return nil
@@ -212,5 +434,59 @@ func (c *NexusConfig) validateTargetServerConfigs(syncConfig *SyncConfig, index
 		c.Client.SyncConfigs[index].DstServerConfig.Pass = c.Client.SyncGlobalAuth.DstServerPass
 	}
 
+	// Set global TLS options if where is no specific one
+	if syncConfig.SrcServerConfig.TLS == (TLSClient{}) {
+		c.Client.SyncConfigs[index].SrcServerConfig.TLS = c.Client.SyncGlobalAuth.SrcServerTLS
+	}
+	if syncConfig.DstServerConfig.TLS == (TLSClient{}) {
+		c.Client.SyncConfigs[index].DstServerConfig.TLS = c.Client.SyncGlobalAuth.DstServerTLS
+	}
+	if err := c.validateTLSClient(fmt.Sprintf("syncConfigs[%d].srcServerConfig.tls", index),
+		syncConfig.SrcServerConfig.TLS); err != nil {
+		return fmt.Errorf("validateTargetServerConfigs: %w", err)
+	}
+	if err := c.validateTLSClient(fmt.Sprintf("syncConfigs[%d].dstServerConfig.tls", index),
+		syncConfig.DstServerConfig.TLS); err != nil {
+		return fmt.Errorf("validateTargetServerConfigs: %w", err)
+	}
+
+	return nil
+}
+
+// validateUpstreamAuth will check that only one kind of artifacts source credentials is set
+func (c *NexusConfig) validateUpstreamAuth(name string, a UpstreamAuth) error {
+	var kinds int
+	for _, v := range []bool{a.User != "" || a.Pass != "", a.Token != "", a.Npmrc != ""} {
+		if v {
+			kinds++
+		}
+	}
+	if kinds > 1 {
+		return &utils.ContextError{
+			Context: "validateUpstreamAuth",
+			Err:     fmt.Errorf("only one of 'user/pass', 'token' or 'npmrc' may be set in '%s' in %s", name, c.string),
+		}
+	}
+	if a.Pass != "" && a.User == "" {
+		return &utils.ContextError{
+			Context: "validateUpstreamAuth",
+			Err:     fmt.Errorf("'%s.user' is missing in %s", name, c.string),
+		}
+	}
+	return nil
+}
+
+// validateTLSClient will check TLS options of connection. Disabled certificate verification is reported loudly
+func (c *NexusConfig) validateTLSClient(name string, t TLSClient) error {
+	if (t.CertPath == "") != (t.KeyPath == "") {
+		return &utils.ContextError{
+			Context: "validateTLSClient",
+			Err:     fmt.Errorf("'%s.certPath' and '%s.keyPath' must be set together in %s", name, name, c.string),
+		}
+	}
+	if t.InsecureSkipVerify {
+		log.Warnf("!!! '%s.insecureSkipVerify' is enabled in %s. TLS certificates are NOT verified, "+
+			"so connection is open to man-in-the-middle attacks. Don't use it in production !!!", name, c.string)
+	}
 	return nil
 }
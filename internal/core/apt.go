@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+// aptArchiveAreas is a list of debian archive areas to search package
+// in, when asset pool path doesn't contain it (nexus hosted layout)
+var aptArchiveAreas = []string{"main", "contrib", "non-free"}
+
+type Apt struct {
+	Server   string
+	Upstream *Upstream
+	Path     string
+	FileName string
+}
+
+func NewApt(upstream *Upstream, path string, fileName string) *Apt {
+	return &Apt{
+		Server:   upstream.URL,
+		Upstream: upstream,
+		Path:     path,
+		FileName: fileName,
+	}
+}
+
+func (a Apt) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	// Get APT package trying every possible mirror location
+	var resp *http.Response
+	for _, assetURL := range a.assetDownloadURLs() {
+		req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
+		if err != nil {
+			return nil, fmt.Errorf("DownloadAsset: %w", err)
+		}
+
+		req.Header.Set("Accept", "application/octet-stream")
+
+		// Send request
+		resp, err = a.Upstream.do(req, 180) // Set 3 min timeout to handle files
+		if err != nil {
+			return nil, fmt.Errorf("DownloadAsset: %w", err)
+		}
+		if resp.StatusCode != http.StatusNotFound {
+			return resp, nil
+		}
+		resp.Body.Close()
+	}
+	// Return last 'not found' response to report it
+	return resp, nil
+}
+
+func (a *Apt) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
+	// Create multipart asset
+	boundary := utils.GenRandomBoundary(32)
+	fileName := a.FileName
+	const fileHeader = "Content-type: application/octet-stream"
+	const fileType = "apt.asset"
+	const fileFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\n%s\r\n\r\n"
+	bodyTop := fmt.Sprintf(fileFormat, boundary, fileType, fileName, fileHeader)
+	bodyBottom := fmt.Sprintf("\r\n--%s--\r\n", boundary)
+
+	body := io.MultiReader(strings.NewReader(bodyTop), fileReader, strings.NewReader(bodyBottom))
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return contentType, body
+}
+
+// assetDownloadURLs maps asset pool path to debian mirror urls. Nexus hosted repository
+// keeps packages at 'pool/<prefix>/<name>/<file>' while mirrors use
+// 'pool/<area>/<prefix>/<name>/<file>', so every archive area is returned as candidate
+func (a Apt) assetDownloadURLs() []string {
+	server := removeLastSlash(a.Server)
+	path := strings.TrimPrefix(a.Path, "/")
+	poolPath := strings.Split(path, "/")
+
+	// Path already has mirror layout (i.e. synced from apt-proxy repository)
+	if len(poolPath) != 4 || poolPath[0] != "pool" {
+		return []string{fmt.Sprintf("%s/%s", server, path)}
+	}
+
+	urls := make([]string, 0, len(aptArchiveAreas))
+	for _, area := range aptArchiveAreas {
+		urls = append(urls, fmt.Sprintf("%s/pool/%s/%s", server, area, strings.Join(poolPath[1:], "/")))
+	}
+	return urls
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestApt_assetDownloadURLs(t *testing.T) {
+	type fields struct {
+		Server string
+		Path   string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   []string
+	}{
+		{
+			name:   "test1",
+			fields: fields{Server: "http://deb.debian.org/debian/", Path: "pool/n/nginx/nginx_1.18.0-6_amd64.deb"},
+			want: []string{
+				"http://deb.debian.org/debian/pool/main/n/nginx/nginx_1.18.0-6_amd64.deb",
+				"http://deb.debian.org/debian/pool/contrib/n/nginx/nginx_1.18.0-6_amd64.deb",
+				"http://deb.debian.org/debian/pool/non-free/n/nginx/nginx_1.18.0-6_amd64.deb",
+			},
+		},
+		{
+			name:   "test2",
+			fields: fields{Server: "http://deb.debian.org/debian", Path: "pool/main/n/nginx/nginx_1.18.0-6_amd64.deb"},
+			want:   []string{"http://deb.debian.org/debian/pool/main/n/nginx/nginx_1.18.0-6_amd64.deb"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := Apt{
+				Server: tt.fields.Server,
+				Path:   tt.fields.Path,
+			}
+			if got := a.assetDownloadURLs(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("assetDownloadURLs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
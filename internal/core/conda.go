@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type Conda struct {
+	Server   string
+	Upstream *Upstream
+	Path     string
+	FileName string
+}
+
+func NewConda(upstream *Upstream, path string, fileName string) *Conda {
+	return &Conda{
+		Server:   upstream.URL,
+		Upstream: upstream,
+		Path:     path,
+		FileName: fileName,
+	}
+}
+
+func (c Conda) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	// Get CONDA package
+	req, err := http.NewRequestWithContext(ctx, "GET", c.assetDownloadURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Send request
+	return c.Upstream.do(req, 900) // Set 15 min timeout to handle large files
+}
+
+// PrepareAssetToUpload returns package data as is, because
+// conda hosted repository accepts packages with plain PUT request
+func (c *Conda) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
+	return "application/octet-stream", fileReader
+}
+
+// assetDownloadURL returns '<channel>/<subdir>/<file>' url
+func (c Conda) assetDownloadURL() string {
+	return fmt.Sprintf("%s/%s", removeLastSlash(c.Server), c.subdirPath())
+}
+
+// subdirPath returns '<subdir>/<file>' part of asset path (i.e. 'linux-64/numpy-1.21.0-py39h.tar.bz2')
+func (c Conda) subdirPath() string {
+	pathSplit := strings.Split(strings.TrimPrefix(c.Path, "/"), "/")
+	if len(pathSplit) < 2 {
+		return c.FileName
+	}
+	return strings.Join(pathSplit[len(pathSplit)-2:], "/")
+}
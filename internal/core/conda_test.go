@@ -0,0 +1,41 @@
+package core
+
+import "testing"
+
+func TestConda_assetDownloadURL(t *testing.T) {
+	type fields struct {
+		Server   string
+		Path     string
+		FileName string
+	}
+	tests := []struct {
+		name   string
+		fields fields
+		want   string
+	}{
+		{
+			name: "test1",
+			fields: fields{Server: "https://conda.anaconda.org/conda-forge/",
+				Path: "linux-64/numpy-1.21.0-py39h.tar.bz2", FileName: "numpy-1.21.0-py39h.tar.bz2"},
+			want: "https://conda.anaconda.org/conda-forge/linux-64/numpy-1.21.0-py39h.tar.bz2",
+		},
+		{
+			name: "test2",
+			fields: fields{Server: "https://repo.anaconda.com/pkgs/main",
+				Path: "/main/noarch/six-1.16.0-pyhd3eb1b0_1.conda", FileName: "six-1.16.0-pyhd3eb1b0_1.conda"},
+			want: "https://repo.anaconda.com/pkgs/main/noarch/six-1.16.0-pyhd3eb1b0_1.conda",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := Conda{
+				Server:   tt.fields.Server,
+				Path:     tt.fields.Path,
+				FileName: tt.fields.FileName,
+			}
+			if got := c.assetDownloadURL(); got != tt.want {
+				t.Errorf("assetDownloadURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,492 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"nexus-pusher/pkg/http_clients"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+const (
+	dockerManifestV2     = "application/vnd.docker.distribution.manifest.v2+json"
+	dockerManifestListV2 = "application/vnd.docker.distribution.manifest.list.v2+json"
+	ociManifestV1        = "application/vnd.oci.image.manifest.v1+json"
+	ociIndexV1           = "application/vnd.oci.image.index.v1+json"
+	dockerForeignLayer   = "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip"
+)
+
+// Docker is used to copy image from upstream registry to destination docker connector
+type Docker struct {
+	Name     string
+	Tag      string
+	upstream *registryClient
+	target   *registryClient
+}
+
+func NewDocker(upstream *Upstream, connector string, connectorTLS *tls.Config, user string, pass string,
+	name string, tag string) *Docker {
+	d := &Docker{
+		Name:     name,
+		Tag:      tag,
+		upstream: &registryClient{server: removeLastSlash(upstream.URL), tls: upstream.TLS},
+		target: &registryClient{server: removeLastSlash(connector), tls: connectorTLS,
+			username: user, password: pass},
+	}
+	// Static bearer token of upstream is used until registry asks for another one
+	if upstream.Auth != nil {
+		d.upstream.username = upstream.Auth.Username
+		d.upstream.password = upstream.Auth.Password
+		d.upstream.token = upstream.Auth.Token
+		d.upstream.headers = upstream.Auth.Headers
+	}
+	return d
+}
+
+type (
+	dockerDescriptor struct {
+		MediaType string `json:"mediaType"`
+		Digest    string `json:"digest"`
+		Size      int64  `json:"size"`
+	}
+
+	dockerManifest struct {
+		MediaType string             `json:"mediaType"`
+		Config    dockerDescriptor   `json:"config"`
+		Layers    []dockerDescriptor `json:"layers"`
+		Manifests []dockerDescriptor `json:"manifests"`
+	}
+)
+
+// isIndex check if manifest is a list of platform specific manifests
+func (m dockerManifest) isIndex(mediaType string) bool {
+	return mediaType == dockerManifestListV2 || mediaType == ociIndexV1 || len(m.Manifests) != 0
+}
+
+// blobs returns all blobs which must be present in registry before manifest push
+func (m dockerManifest) blobs() []dockerDescriptor {
+	blobs := make([]dockerDescriptor, 0, len(m.Layers)+1)
+	if m.Config.Digest != "" {
+		blobs = append(blobs, m.Config)
+	}
+	for _, v := range m.Layers {
+		// Foreign layers can't be pushed to registry by design
+		if v.MediaType == dockerForeignLayer {
+			continue
+		}
+		blobs = append(blobs, v)
+	}
+	return blobs
+}
+
+// CopyImage copies image manifest with all referenced blobs to destination registry
+func (d Docker) CopyImage(ctx context.Context) error {
+	if err := d.copyManifest(ctx, d.Tag); err != nil {
+		return fmt.Errorf("CopyImage: %w", err)
+	}
+	return nil
+}
+
+func (d Docker) copyManifest(ctx context.Context, reference string) error {
+	body, mediaType, err := d.upstream.getManifest(ctx, d.upstreamName(), reference)
+	if err != nil {
+		return &downloadError{fmt.Errorf("copyManifest: %w", err)}
+	}
+
+	var manifest dockerManifest
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return fmt.Errorf("copyManifest: %w", err)
+	}
+	if mediaType == "" {
+		mediaType = manifest.MediaType
+	}
+
+	if manifest.isIndex(mediaType) {
+		// Copy every platform specific manifest before the index itself
+		for _, v := range manifest.Manifests {
+			if err := d.copyManifest(ctx, v.Digest); err != nil {
+				return fmt.Errorf("copyManifest: %w", err)
+			}
+		}
+	} else {
+		for _, v := range manifest.blobs() {
+			if err := d.copyBlob(ctx, v); err != nil {
+				return fmt.Errorf("copyManifest: %w", err)
+			}
+		}
+	}
+
+	if err := d.target.putManifest(ctx, d.Name, reference, mediaType, body); err != nil {
+		return fmt.Errorf("copyManifest: %w", err)
+	}
+	return nil
+}
+
+func (d Docker) copyBlob(ctx context.Context, blob dockerDescriptor) error {
+	// Skip blobs which are already uploaded to destination
+	exists, err := d.target.blobExists(ctx, d.Name, blob.Digest)
+	if err != nil {
+		return fmt.Errorf("copyBlob: %w", err)
+	}
+	if exists {
+		return nil
+	}
+	if blob.Size <= 0 {
+		return &utils.ContextError{
+			Context: "copyBlob",
+			Err:     fmt.Errorf("blob %s has no size in manifest", blob.Digest),
+		}
+	}
+
+	// Blob is downloaded again if upload must be repeated with a new token
+	open := func() (io.ReadCloser, error) {
+		resp, err := d.upstream.getBlob(ctx, d.upstreamName(), blob.Digest)
+		if err != nil {
+			return nil, &downloadError{fmt.Errorf("copyBlob: %w", err)}
+		}
+		if resp.ContentLength >= 0 && resp.ContentLength != blob.Size {
+			resp.Body.Close()
+			return nil, &downloadError{&utils.ContextError{
+				Context: "copyBlob",
+				Err: fmt.Errorf("blob %s size %d differs from manifest size %d",
+					blob.Digest, resp.ContentLength, blob.Size),
+			}}
+		}
+		return countTransferred(ctx, resp.Body), nil
+	}
+
+	if err := d.target.putBlob(ctx, d.Name, blob.Digest, blob.Size, open); err != nil {
+		return fmt.Errorf("copyBlob: %w", err)
+	}
+	return nil
+}
+
+// upstreamName returns image name with 'library' namespace for official docker hub images
+func (d Docker) upstreamName() string {
+	if strings.Contains(d.upstream.server, "docker.io") && !strings.Contains(d.Name, "/") {
+		return fmt.Sprintf("library/%s", d.Name)
+	}
+	return d.Name
+}
+
+// registryClient is a minimal docker registry v2 API client
+type registryClient struct {
+	server string
+	// tls is holding connection options of registry, nil for defaults
+	tls *tls.Config
+	// headers is a custom headers sent with every registry request
+	headers  map[string]string
+	username string
+	password string
+	token    string
+}
+
+func (r *registryClient) getManifest(ctx context.Context, name string, reference string) ([]byte, string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v2/%s/manifests/%s", r.server, name, reference), nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("getManifest: %w", err)
+	}
+	req.Header.Set("Accept", strings.Join([]string{
+		dockerManifestV2, dockerManifestListV2, ociManifestV1, ociIndexV1}, ", "))
+
+	resp, err := r.do(http_clients.HttpRetryClientTLS(r.tls), req)
+	if err != nil {
+		return nil, "", fmt.Errorf("getManifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", registryError("getManifest", resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("getManifest: %w", err)
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
+
+func (r *registryClient) putManifest(ctx context.Context, name string, reference string, mediaType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, "PUT", fmt.Sprintf("%s/v2/%s/manifests/%s", r.server, name, reference),
+		bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("putManifest: %w", err)
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := r.do(http_clients.HttpRetryClientTLS(r.tls), req)
+	if err != nil {
+		return fmt.Errorf("putManifest: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return registryError("putManifest", resp)
+	}
+	return nil
+}
+
+func (r *registryClient) blobExists(ctx context.Context, name string, digest string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", fmt.Sprintf("%s/v2/%s/blobs/%s", r.server, name, digest), nil)
+	if err != nil {
+		return false, fmt.Errorf("blobExists: %w", err)
+	}
+
+	resp, err := r.do(http_clients.HttpRetryClientTLS(r.tls), req)
+	if err != nil {
+		return false, fmt.Errorf("blobExists: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return true, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, registryError("blobExists", resp)
+	}
+}
+
+func (r *registryClient) getBlob(ctx context.Context, name string, digest string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/v2/%s/blobs/%s", r.server, name, digest), nil)
+	if err != nil {
+		return nil, fmt.Errorf("getBlob: %w", err)
+	}
+
+	// Set 15 min timeout to handle large layers
+	resp, err := r.do(http_clients.HttpRetryClientTLS(r.tls, 900), req)
+	if err != nil {
+		return nil, fmt.Errorf("getBlob: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, registryError("getBlob", resp)
+	}
+	return resp, nil
+}
+
+// putBlob uploads blob with monolithic upload (POST to get upload location, then PUT data).
+// Blob data is streamed from open with explicit size, open is called again if upload is repeated
+func (r *registryClient) putBlob(ctx context.Context, name string, digest string, size int64,
+	open func() (io.ReadCloser, error)) error {
+	body, err := open()
+	if err != nil {
+		return fmt.Errorf("putBlob: %w", err)
+	}
+
+	// Registry auth is settled by upload session request, so blob data is sent with a valid token
+	req, err := http.NewRequestWithContext(ctx, "POST", fmt.Sprintf("%s/v2/%s/blobs/uploads/", r.server, name), nil)
+	if err != nil {
+		body.Close()
+		return fmt.Errorf("putBlob: %w", err)
+	}
+
+	resp, err := r.do(http_clients.HttpRetryClientTLS(r.tls), req)
+	if err != nil {
+		body.Close()
+		return fmt.Errorf("putBlob: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted {
+		body.Close()
+		return registryError("putBlob", resp)
+	}
+
+	uploadURL, err := uploadLocation(r.server, resp.Header.Get("Location"), digest)
+	if err != nil {
+		body.Close()
+		return fmt.Errorf("putBlob: %w", err)
+	}
+
+	req, err = http.NewRequestWithContext(ctx, "PUT", uploadURL, body)
+	if err != nil {
+		body.Close()
+		return fmt.Errorf("putBlob: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	// Stream is never sent chunked, registries require length of monolithic upload
+	req.ContentLength = size
+	req.GetBody = open
+
+	// We can't use retryable client here because of direct stream data
+	// Set 15 min timeout to handle large layers
+	resp, err = r.do(http_clients.HttpClientTLS(r.tls, 900), req)
+	if err != nil {
+		return fmt.Errorf("putBlob: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return registryError("putBlob", resp)
+	}
+	return nil
+}
+
+// do send request with registry auth. If registry requires bearer token,
+// request token following 'WWW-Authenticate' challenge and repeat request
+func (r *registryClient) do(c *http.Client, req *http.Request) (*http.Response, error) {
+	r.setAuth(req)
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request body can't be sent twice if it's a stream which can't be opened again
+	if resp.StatusCode != http.StatusUnauthorized || (req.Body != nil && req.GetBody == nil) {
+		return resp, nil
+	}
+
+	challenge := parseBearerChallenge(resp.Header.Get("WWW-Authenticate"))
+	if challenge["realm"] == "" {
+		return resp, nil
+	}
+	resp.Body.Close()
+
+	if err := r.requestToken(req.Context(), challenge); err != nil {
+		return nil, err
+	}
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		if retry.Body, err = req.GetBody(); err != nil {
+			return nil, err
+		}
+	}
+	r.setAuth(retry)
+	return c.Do(retry)
+}
+
+func (r *registryClient) setAuth(req *http.Request) {
+	for k, v := range r.headers {
+		req.Header.Set(k, v)
+	}
+	if r.token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", r.token))
+	} else if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+}
+
+func (r *registryClient) requestToken(ctx context.Context, challenge map[string]string) error {
+	tokenURL, err := url.Parse(challenge["realm"])
+	if err != nil {
+		return fmt.Errorf("requestToken: %w", err)
+	}
+	q := tokenURL.Query()
+	if challenge["service"] != "" {
+		q.Set("service", challenge["service"])
+	}
+	if challenge["scope"] != "" {
+		q.Set("scope", challenge["scope"])
+	}
+	tokenURL.RawQuery = q.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", tokenURL.String(), nil)
+	if err != nil {
+		return fmt.Errorf("requestToken: %w", err)
+	}
+	if r.username != "" {
+		req.SetBasicAuth(r.username, r.password)
+	}
+
+	resp, err := http_clients.HttpRetryClientTLS(r.tls).Do(req)
+	if err != nil {
+		return fmt.Errorf("requestToken: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return registryError("requestToken", resp)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("requestToken: %w", err)
+	}
+
+	respJson := &struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.Unmarshal(body, respJson); err != nil {
+		return fmt.Errorf("requestToken: %w", err)
+	}
+
+	r.token = respJson.Token
+	if r.token == "" {
+		r.token = respJson.AccessToken
+	}
+	return nil
+}
+
+// parseBearerChallenge parse 'WWW-Authenticate' header parameters of bearer auth scheme
+func parseBearerChallenge(header string) map[string]string {
+	params := make(map[string]string)
+	const scheme = "bearer "
+	if len(header) < len(scheme) || !strings.EqualFold(header[:len(scheme)], scheme) {
+		return params
+	}
+	// Parameter values are quoted and may contain commas (i.e. scope="repository:name:pull,push")
+	var key, value strings.Builder
+	inValue, inQuotes := false, false
+	flush := func() {
+		if k := strings.ToLower(strings.TrimSpace(key.String())); k != "" {
+			params[k] = strings.TrimSpace(value.String())
+		}
+		key.Reset()
+		value.Reset()
+		inValue = false
+	}
+	for _, ch := range header[len(scheme):] {
+		switch {
+		case ch == '"':
+			inQuotes = !inQuotes
+		case ch == ',' && !inQuotes:
+			flush()
+		case ch == '=' && !inValue:
+			inValue = true
+		case inValue:
+			value.WriteRune(ch)
+		default:
+			key.WriteRune(ch)
+		}
+	}
+	flush()
+	return params
+}
+
+// uploadLocation returns absolute blob upload url with digest parameter
+func uploadLocation(server string, location string, digest string) (string, error) {
+	baseURL, err := url.Parse(server)
+	if err != nil {
+		return "", fmt.Errorf("uploadLocation: %w", err)
+	}
+	uploadURL, err := baseURL.Parse(location)
+	if err != nil {
+		return "", fmt.Errorf("uploadLocation: %w", err)
+	}
+	q := uploadURL.Query()
+	q.Set("digest", digest)
+	uploadURL.RawQuery = q.Encode()
+	return uploadURL.String(), nil
+}
+
+func registryError(context string, resp *http.Response) error {
+	return &utils.ContextError{
+		Context: context,
+		Err: fmt.Errorf("error: sending '%s' request: status code %d %v",
+			resp.Request.Method,
+			resp.StatusCode,
+			resp.Request.URL),
+	}
+}
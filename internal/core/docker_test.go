@@ -0,0 +1,150 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseBearerChallenge(t *testing.T) {
+	type args struct {
+		header string
+	}
+	tests := []struct {
+		name string
+		args args
+		want map[string]string
+	}{
+		{
+			name: "test1",
+			args: args{header: `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",` +
+				`scope="repository:library/nginx:pull,push"`},
+			want: map[string]string{
+				"realm":   "https://auth.docker.io/token",
+				"service": "registry.docker.io",
+				"scope":   "repository:library/nginx:pull,push",
+			},
+		},
+		{
+			name: "test2",
+			args: args{header: `Basic realm="Sonatype Nexus Repository Manager"`},
+			want: map[string]string{},
+		},
+		{
+			name: "test3",
+			args: args{header: ""},
+			want: map[string]string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseBearerChallenge(tt.args.header); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseBearerChallenge() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_uploadLocation(t *testing.T) {
+	type args struct {
+		server   string
+		location string
+		digest   string
+	}
+	tests := []struct {
+		name string
+		args args
+		want string
+	}{
+		{
+			name: "test1",
+			args: args{server: "https://nexus.some:8083", location: "/v2/nginx/blobs/uploads/123",
+				digest: "sha256:abc"},
+			want: "https://nexus.some:8083/v2/nginx/blobs/uploads/123?digest=sha256%3Aabc",
+		},
+		{
+			name: "test2",
+			args: args{server: "https://nexus.some:8083", location: "https://other.some/v2/nginx/blobs/uploads/1?_state=x",
+				digest: "sha256:abc"},
+			want: "https://other.some/v2/nginx/blobs/uploads/1?_state=x&digest=sha256%3Aabc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := uploadLocation(tt.args.server, tt.args.location, tt.args.digest)
+			if err != nil {
+				t.Errorf("uploadLocation() error = %v", err)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("uploadLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_dockerManifest_blobs(t *testing.T) {
+	m := dockerManifest{
+		Config: dockerDescriptor{Digest: "sha256:config"},
+		Layers: []dockerDescriptor{
+			{Digest: "sha256:layer1"},
+			{Digest: "sha256:foreign", MediaType: dockerForeignLayer},
+			{Digest: "sha256:layer2"},
+		},
+	}
+	want := []dockerDescriptor{
+		{Digest: "sha256:config"},
+		{Digest: "sha256:layer1"},
+		{Digest: "sha256:layer2"},
+	}
+	if got := m.blobs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("blobs() = %v, want %v", got, want)
+	}
+}
+
+func Test_registryClient_putBlob(t *testing.T) {
+	const blob = "blob data"
+	var puts []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/token":
+			fmt.Fprint(w, `{"token":"push-token"}`)
+		case r.Method == "POST":
+			w.Header().Set("Location", "/v2/image/blobs/uploads/1")
+			w.WriteHeader(http.StatusAccepted)
+		case r.Method == "PUT":
+			body, _ := ioutil.ReadAll(r.Body)
+			puts = append(puts, fmt.Sprintf("%d %v %s", r.ContentLength, r.TransferEncoding, body))
+			// Token of upload session is expired by the time blob is sent
+			if r.Header.Get("Authorization") != "Bearer push-token" {
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token"`, server.URL))
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	defer server.Close()
+
+	opened := 0
+	open := func() (io.ReadCloser, error) {
+		opened++
+		return ioutil.NopCloser(strings.NewReader(blob)), nil
+	}
+	r := &registryClient{server: server.URL}
+	if err := r.putBlob(context.Background(), "image", "sha256:1", int64(len(blob)), open); err != nil {
+		t.Fatalf("putBlob() error = %v", err)
+	}
+
+	want := []string{"9 [] blob data", "9 [] blob data"}
+	if !reflect.DeepEqual(puts, want) || opened != 2 {
+		t.Errorf("putBlob() puts = %q, opened %d times, want %q, opened 2 times", puts, opened, want)
+	}
+}
@@ -5,6 +5,8 @@ import "fmt"
 type NexusExportComponents struct {
 	NexusServer NexusServer             `json:"nexusServer"`
 	Items       []*NexusExportComponent `json:"items"`
+	// Upstreams is holding credentials of artifacts sources
+	Upstreams []*Upstream `json:"upstreams,omitempty"`
 }
 
 type NexusExportComponent struct {
@@ -15,10 +17,44 @@ type NexusExportComponent struct {
 	Group           string                       `json:"group"`
 	ArtifactsSource string                       `json:"artifactsSource"`
 	Assets          []*NexusExportComponentAsset `json:"assets"`
+	// FallbackSources is holding artifacts sources tried in order when primary one fails
+	FallbackSources []string `json:"fallbackSources,omitempty"`
+	// chunk is an index of raw bundle among bundles of the same directory
+	chunk int
+}
+
+// UploadPath returns unique key of component which is uploaded as a whole, it's used to track
+// upload progress. Group is set for components which may share name, raw bundles are numbered
+func (n NexusExportComponent) UploadPath() string {
+	path := n.Name
+	if n.Group != "" {
+		path = fmt.Sprintf("%s:%s", n.Group, path)
+	}
+	if n.Version != "" {
+		path = fmt.Sprintf("%s@%s", path, n.Version)
+	}
+	if n.chunk != 0 {
+		path = fmt.Sprintf("%s#%d", path, n.chunk)
+	}
+	return path
+}
+
+// Sources returns primary artifacts source followed by fallback ones
+func (n NexusExportComponent) Sources() []string {
+	return append([]string{n.ArtifactsSource}, n.FallbackSources...)
+}
+
+// packageKey returns key of package which artifacts source is remembered by
+func (n NexusExportComponent) packageKey() string {
+	return fmt.Sprintf("%s/%s/%s/%s", n.Format, n.Repository, n.Group, n.Name)
 }
 
 // FullName returns name and version for component
 func (n NexusExportComponent) FullName() string {
+	// Bundled raw assets don't have any version
+	if n.Version == "" {
+		return n.Name
+	}
 	return fmt.Sprintf("%s-%s", n.Name, n.Version)
 }
 
@@ -28,6 +64,8 @@ type NexusExportComponentAsset struct {
 	Version     string `json:"version"`
 	Path        string `json:"path"`
 	ContentType string `json:"contentType"`
+	// DownloadURL is set if asset is downloaded from source nexus repository instead of artifacts source
+	DownloadURL string `json:"downloadUrl,omitempty"`
 }
 
 // FullName returns name and version for asset
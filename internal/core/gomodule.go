@@ -0,0 +1,113 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"nexus-pusher/pkg/http_clients"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+// goModuleFiles is a list of files which go command requests for every module version
+var goModuleFiles = []string{"info", "mod", "zip"}
+
+// GoModule is used to warm nexus go proxy repository. There is no upload API for go
+// format, so all module files are requested through destination proxy to cache them
+type GoModule struct {
+	Server    string
+	Upstream  *Upstream
+	Proxy     string
+	ProxyTLS  *tls.Config
+	Username  string
+	Password  string
+	Component *NexusExportComponent
+}
+
+func NewGoModule(upstream *Upstream, proxy string, proxyTLS *tls.Config, user string, pass string,
+	component *NexusExportComponent) *GoModule {
+	return &GoModule{
+		Server:    upstream.URL,
+		Upstream:  upstream,
+		Proxy:     proxy,
+		ProxyTLS:  proxyTLS,
+		Username:  user,
+		Password:  pass,
+		Component: component,
+	}
+}
+
+// WarmProxy check module version at upstream and request all module files through destination proxy
+func (g GoModule) WarmProxy(ctx context.Context) error {
+	modulePath, err := g.modulePath()
+	if err != nil {
+		return fmt.Errorf("WarmProxy: %w", err)
+	}
+
+	// Check module version is available at upstream to report clear error
+	// instead of proxy one, which doesn't tell what was wrong
+	upstreamURL := fmt.Sprintf("%s/%s/@v/%s.info", removeLastSlash(g.Server), modulePath, g.Component.Version)
+	if err := g.fetch(ctx, g.Upstream.client(), upstreamURL, false); err != nil {
+		return &downloadError{fmt.Errorf("WarmProxy: %w", err)}
+	}
+
+	for _, v := range goModuleFiles {
+		proxyURL := fmt.Sprintf("%s/%s/@v/%s.%s", removeLastSlash(g.Proxy), modulePath, g.Component.Version, v)
+		// Set 15 min timeout, because proxy has to download module archive first
+		if err := g.fetch(ctx, http_clients.HttpRetryClientTLS(g.ProxyTLS, 900), proxyURL, true); err != nil {
+			return fmt.Errorf("WarmProxy: %w", err)
+		}
+	}
+	return nil
+}
+
+func (g GoModule) fetch(ctx context.Context, c *http.Client, requestURL string, withAuth bool) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	if withAuth {
+		req.SetBasicAuth(g.Username, g.Password)
+	} else {
+		g.Upstream.authorize(req)
+	}
+
+	// Send request
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return &utils.ContextError{
+			Context: "fetch",
+			Err: fmt.Errorf("error: sending '%s' request: status code %d %v",
+				resp.Request.Method,
+				resp.StatusCode,
+				resp.Request.URL),
+		}
+	}
+
+	// Read all body data, so proxy could finish caching it
+	if _, err := io.Copy(ioutil.Discard, countTransferred(ctx, resp.Body)); err != nil {
+		return fmt.Errorf("fetch: %w", err)
+	}
+	return nil
+}
+
+// modulePath returns escaped module path from asset path (i.e. 'github.com/!burnt!sushi/toml')
+func (g GoModule) modulePath() (string, error) {
+	for _, v := range g.Component.Assets {
+		if i := strings.Index(v.Path, "/@v/"); i != -1 {
+			return strings.TrimPrefix(v.Path[:i], "/"), nil
+		}
+	}
+	return "", &utils.ContextError{
+		Context: "modulePath",
+		Err:     fmt.Errorf("error: unable to find module path for component %s", g.Component.FullName()),
+	}
+}
@@ -0,0 +1,41 @@
+package core
+
+import "testing"
+
+func TestGoModule_modulePath(t *testing.T) {
+	tests := []struct {
+		name    string
+		assets  []*NexusExportComponentAsset
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "test1",
+			assets: []*NexusExportComponentAsset{{Path: "github.com/!burnt!sushi/toml/@v/v1.0.0.zip"}},
+			want:   "github.com/!burnt!sushi/toml",
+		},
+		{
+			name:   "test2",
+			assets: []*NexusExportComponentAsset{{Path: "/golang.org/x/sync/@v/v0.1.0.info"}},
+			want:   "golang.org/x/sync",
+		},
+		{
+			name:    "test3",
+			assets:  []*NexusExportComponentAsset{{Path: "golang.org/x/sync/@latest"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := GoModule{Component: &NexusExportComponent{Assets: tt.assets}}
+			got, err := g.modulePath()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("modulePath() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("modulePath() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+type Helm struct {
+	Server   string
+	Upstream *Upstream
+	FileName string
+	Name     string
+	Version  string
+}
+
+func NewHelm(upstream *Upstream, fileName string, name string, version string) *Helm {
+	return &Helm{
+		Server:   upstream.URL,
+		Upstream: upstream,
+		FileName: fileName,
+		Name:     name,
+		Version:  version,
+	}
+}
+
+func (h Helm) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	// Get HELM chart
+	assetURL, err := h.assetDownloadURL(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Send request
+	return h.Upstream.do(req, 180) // Set 3 min timeout to handle files
+}
+
+func (h *Helm) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
+	// Create multipart asset
+	boundary := utils.GenRandomBoundary(32)
+	fileName := h.FileName
+	const fileHeader = "Content-type: application/octet-stream"
+	const fileType = "helm.asset"
+	const fileFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\n%s\r\n\r\n"
+	bodyTop := fmt.Sprintf(fileFormat, boundary, fileType, fileName, fileHeader)
+	bodyBottom := fmt.Sprintf("\r\n--%s--\r\n", boundary)
+
+	body := io.MultiReader(strings.NewReader(bodyTop), fileReader, strings.NewReader(bodyBottom))
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return contentType, body
+}
+
+// helmIndex is holding chart versions of helm repository by chart name
+type helmIndex map[string][]struct {
+	Version string   `yaml:"version"`
+	URLs    []string `yaml:"urls"`
+}
+
+func (h Helm) assetDownloadURL(ctx context.Context) (string, error) {
+	// Repository index is shared by all charts, so it's downloaded once per job
+	index, err := repositoryIndex(ctx, "helm:"+removeLastSlash(h.Server), func() (interface{}, error) {
+		return h.index(ctx)
+	})
+	if err != nil {
+		return "", fmt.Errorf("assetDownloadURL: %w", err)
+	}
+
+	return h.chartURL(index.(helmIndex))
+}
+
+// index returns parsed index.yaml of helm repository
+func (h Helm) index(ctx context.Context) (helmIndex, error) {
+	// Chart repository index is always located at the repository root
+	requestURL := fmt.Sprintf("%s/index.yaml", removeLastSlash(h.Server))
+
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/x-yaml")
+
+	// Send request
+	resp, err := h.Upstream.do(req, 180) // Index files of big repos can be huge
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check response for error
+	if resp.StatusCode != http.StatusOK {
+		return nil, &utils.ContextError{
+			Context: "index",
+			Err: fmt.Errorf("error: unable to get index for helm repository. sending '%s' request: status code %d %v",
+				resp.Request.Method,
+				resp.StatusCode,
+				resp.Request.URL),
+		}
+	}
+
+	// Read response body
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+
+	return parseHelmIndex(body)
+}
+
+// parseHelmIndex returns chart versions from helm repository index.yaml data
+func parseHelmIndex(body []byte) (helmIndex, error) {
+	// Parse only required part of index file
+	index := &struct {
+		Entries helmIndex `yaml:"entries"`
+	}{}
+	if err := yaml.Unmarshal(body, index); err != nil {
+		return nil, fmt.Errorf("parseHelmIndex: %w", err)
+	}
+	return index.Entries, nil
+}
+
+// chartURL search chart download url in helm repository index
+func (h Helm) chartURL(index helmIndex) (string, error) {
+	for _, v := range index[h.Name] {
+		if v.Version != h.Version || len(v.URLs) == 0 {
+			continue
+		}
+		// Chart url can be relative to repository url, so resolve it
+		baseURL, err := url.Parse(fmt.Sprintf("%s/", removeLastSlash(h.Server)))
+		if err != nil {
+			return "", fmt.Errorf("chartURL: %w", err)
+		}
+		chartURL, err := baseURL.Parse(v.URLs[0])
+		if err != nil {
+			return "", fmt.Errorf("chartURL: %w", err)
+		}
+		return chartURL.String(), nil
+	}
+
+	return "", &utils.ContextError{
+		Context: "chartURL",
+		Err: fmt.Errorf("error: unable to find chart: %s version: %s at: %s",
+			h.Name, h.Version, h.Server),
+	}
+}
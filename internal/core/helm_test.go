@@ -0,0 +1,105 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHelm_chartURL(t *testing.T) {
+	const index = `apiVersion: v1
+entries:
+  nginx:
+    - version: 1.2.0
+      urls:
+        - https://charts.some.org/archive/nginx-1.2.0.tgz
+    - version: 1.1.0
+      urls:
+        - nginx-1.1.0.tgz
+  redis:
+    - version: 7.0.0
+      urls: []
+`
+	type fields struct {
+		Server  string
+		Name    string
+		Version string
+	}
+	tests := []struct {
+		name    string
+		fields  fields
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "test1",
+			fields: fields{Server: "https://charts.some.org/stable", Name: "nginx", Version: "1.2.0"},
+			want:   "https://charts.some.org/archive/nginx-1.2.0.tgz",
+		},
+		{
+			name:   "test2",
+			fields: fields{Server: "https://charts.some.org/stable/", Name: "nginx", Version: "1.1.0"},
+			want:   "https://charts.some.org/stable/nginx-1.1.0.tgz",
+		},
+		{
+			name:    "test3",
+			fields:  fields{Server: "https://charts.some.org/stable", Name: "nginx", Version: "0.1.0"},
+			wantErr: true,
+		},
+		{
+			name:    "test4",
+			fields:  fields{Server: "https://charts.some.org/stable", Name: "redis", Version: "7.0.0"},
+			wantErr: true,
+		},
+	}
+	parsed, err := parseHelmIndex([]byte(index))
+	if err != nil {
+		t.Fatalf("parseHelmIndex() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := Helm{
+				Server:  tt.fields.Server,
+				Name:    tt.fields.Name,
+				Version: tt.fields.Version,
+			}
+			got, err := h.chartURL(parsed)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("chartURL() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("chartURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHelm_assetDownloadURL_indexCache(t *testing.T) {
+	const index = `entries:
+  nginx:
+    - version: 1.2.0
+      urls: [nginx-1.2.0.tgz]
+    - version: 1.1.0
+      urls: [nginx-1.1.0.tgz]
+`
+	var requests int
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(index))
+	}))
+	defer upstream.Close()
+
+	// Index is downloaded once for all charts of job
+	ctx := withIndexCache(context.Background())
+	for _, v := range []string{"1.2.0", "1.1.0"} {
+		h := NewHelm(NewUpstream(upstream.URL), "nginx-"+v+".tgz", "nginx", v)
+		if got, err := h.assetDownloadURL(ctx); err != nil || got != upstream.URL+"/nginx-"+v+".tgz" {
+			t.Errorf("assetDownloadURL() = %v, error = %v", got, err)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("assetDownloadURL() index requests = %d, want 1", requests)
+	}
+}
@@ -0,0 +1,56 @@
+package core
+
+import (
+	"context"
+	"sync"
+)
+
+// indexCacheKey is a context key of repository indexes cache
+type indexCacheKey struct{}
+
+// indexCache keeps parsed indexes of artifacts sources, so repository index is downloaded
+// once per job instead of once per asset
+type indexCache struct {
+	mu      sync.Mutex
+	indexes map[string]*cachedIndex
+}
+
+type cachedIndex struct {
+	mu    sync.Mutex
+	index interface{}
+}
+
+// withIndexCache returns context with empty repository indexes cache
+func withIndexCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, indexCacheKey{}, &indexCache{indexes: make(map[string]*cachedIndex)})
+}
+
+// repositoryIndex returns index by key from context cache, index is loaded once and only successfully
+// loaded index is kept. Index is loaded every time if context has no cache
+func repositoryIndex(ctx context.Context, key string, load func() (interface{}, error)) (interface{}, error) {
+	cache, ok := ctx.Value(indexCacheKey{}).(*indexCache)
+	if !ok {
+		return load()
+	}
+
+	cache.mu.Lock()
+	cached, ok := cache.indexes[key]
+	if !ok {
+		cached = &cachedIndex{}
+		cache.indexes[key] = cached
+	}
+	cache.mu.Unlock()
+
+	// Concurrent requests of the same index wait for the first one
+	cached.mu.Lock()
+	defer cached.mu.Unlock()
+	if cached.index != nil {
+		return cached.index, nil
+	}
+	index, err := load()
+	if err != nil {
+		return nil, err
+	}
+	cached.index = index
+	return index, nil
+}
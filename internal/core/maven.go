@@ -1,39 +1,41 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"nexus-pusher/pkg/http_clients"
 	"nexus-pusher/pkg/utils"
 	"strings"
 )
 
 type Maven2 struct {
 	Server    string
+	Upstream  *Upstream
 	Component *NexusExportComponent
 }
 
-func NewMaven2(server string, component *NexusExportComponent) *Maven2 {
+func NewMaven2(upstream *Upstream, component *NexusExportComponent) *Maven2 {
 	return &Maven2{
-		Server:    server,
+		Server:    upstream.URL,
+		Upstream:  upstream,
 		Component: component,
 	}
 }
 
-func (m Maven2) DownloadComponent() ([]*http.Response, error) {
+func (m Maven2) DownloadComponent(ctx context.Context) ([]*http.Response, error) {
 	// Allocate slice for responses following assets count
 	responses := make([]*http.Response, 0, len(m.Component.Assets))
 
 	for i := range m.Component.Assets {
-		req, err := http.NewRequest("GET", m.assetDownloadURL(i), nil)
+		req, err := http.NewRequestWithContext(ctx, "GET", m.assetDownloadURL(i), nil)
 		if err != nil {
 			return nil, fmt.Errorf("DownloadComponent: %w", err)
 		}
 		req.Header.Set("Accept", "application/octet-stream")
 
 		// Send request
-		resp, err := http_clients.HttpRetryClient(180).Do(req)
+		resp, err := m.Upstream.do(req, 180)
 		if err != nil {
 			return nil, fmt.Errorf("DownloadComponent: %w", err)
 		}
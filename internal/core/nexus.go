@@ -99,6 +99,80 @@ func filterHashAssets(nc *NexusComponents) {
 					log.Debugf("Maven2: filtering '%s' asset from comparison "+
 						"by extension '%s' list", nc.Items[compInd].Assets[assetInd].Path, fileExtension)
 
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.DOCKER.String():
+				// Compare images by manifests only, layer blobs
+				// are copied together with image manifest
+				if !strings.Contains(nc.Items[compInd].Assets[assetInd].Path, "/manifests/") {
+					log.Debugf("Docker: filtering '%s' asset from comparison",
+						nc.Items[compInd].Assets[assetInd].Path)
+
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.RUBY.String():
+				// Compare gem files only. Gemspec, quick index and specs
+				// metadata is generated by nexus for every uploaded gem
+				if filepath.Ext(nc.Items[compInd].Assets[assetInd].Path) != ".gem" {
+					log.Debugf("Rubygems: filtering '%s' metadata asset from comparison",
+						nc.Items[compInd].Assets[assetInd].Path)
+
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.APT.String():
+				// Release and Packages indexes are generated by nexus
+				// for every repository, so compare packages only
+				if strings.HasPrefix(strings.TrimPrefix(nc.Items[compInd].Assets[assetInd].Path, "/"), "dists/") {
+					log.Debugf("Apt: filtering '%s' metadata asset from comparison",
+						nc.Items[compInd].Assets[assetInd].Path)
+
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.YUM.String():
+				// Repository metadata is generated by nexus, so compare packages only
+				assetPath := "/" + strings.TrimPrefix(nc.Items[compInd].Assets[assetInd].Path, "/")
+				if strings.Contains(assetPath, "/repodata/") {
+					log.Debugf("Yum: filtering '%s' metadata asset from comparison",
+						nc.Items[compInd].Assets[assetInd].Path)
+
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.GO.String():
+				// Compare module files only, '@v/list' and '@latest'
+				// are generated by proxy following cached modules
+				switch filepath.Ext(nc.Items[compInd].Assets[assetInd].Path) {
+				case ".info", ".mod", ".zip":
+				default:
+					log.Debugf("Go: filtering '%s' asset from comparison",
+						nc.Items[compInd].Assets[assetInd].Path)
+
+					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
+						nc.Items[compInd].Assets[assetInd+1:]...)
+					// If an asset was filtered - get back to one index position
+					assetInd--
+				}
+			case config.CONDA.String():
+				// Compare packages only, channel metadata ('repodata.json',
+				// 'channeldata.json', etc.) is generated by nexus
+				assetPath := nc.Items[compInd].Assets[assetInd].Path
+				if !strings.HasSuffix(assetPath, ".tar.bz2") && !strings.HasSuffix(assetPath, ".conda") {
+					log.Debugf("Conda: filtering '%s' metadata asset from comparison", assetPath)
+
 					nc.Items[compInd].Assets = append(nc.Items[compInd].Assets[:assetInd],
 						nc.Items[compInd].Assets[assetInd+1:]...)
 					// If an asset was filtered - get back to one index position
@@ -131,8 +205,10 @@ func filterHashAssets(nc *NexusComponents) {
 	}
 }
 
-// UploadComponents is used to upload nexus artifacts following by 'nec' list
-func (s *NexusServer) UploadComponents(nec *NexusExportComponents, repoName string, cs *config.Server) []UploadResult {
+// UploadComponents is used to upload nexus artifacts following by 'nec' list.
+// Components already uploaded according to optional tracker are skipped.
+func (s *NexusServer) UploadComponents(ctx context.Context, nec *NexusExportComponents, repoName string,
+	cs *config.Server, tracker UploadTracker) []UploadResult {
 
 	limitChan := make(chan struct{}, cs.Concurrency)
 	resultsChan := make(chan *UploadResult)
@@ -142,17 +218,69 @@ func (s *NexusServer) UploadComponents(nec *NexusExportComponents, repoName stri
 		close(resultsChan)
 	}()
 
+	// Repository indexes of artifacts sources are shared by all components of request
+	ctx = withIndexCache(ctx)
+
+	isUploaded := func(path string) bool {
+		return tracker != nil && tracker.IsUploaded(path)
+	}
+	// started will report upload start to tracker and return context to count downloaded bytes
+	started := func(path string) context.Context {
+		if tracker == nil {
+			return ctx
+		}
+		tracker.Started(path)
+		return withTransferCounter(ctx, tracker.Transferred)
+	}
+
 	var resultsCounter int
-	for _, v := range nec.Items {
-		if config.ComponentType(v.Format).Bundled() {
+	for _, v := range bundleRawComponents(nec.Items) {
+		if config.ComponentType(v.Format).Lower() == config.DOCKER {
+			if isUploaded(v.UploadPath()) {
+				continue
+			}
+			// Process image with docker registry API
+			resultsCounter++
+			go func(component *NexusExportComponent, repoName string) {
+				limitChan <- struct{}{}
+				result := &UploadResult{ComponentPath: component.UploadPath()}
+				if ctx.Err() != nil {
+					// Don't start new uploads for canceled request
+					result.Err = ctx.Err()
+				} else {
+					ctx := started(component.UploadPath())
+					result.Source, result.Err = s.uploadFromSources(ctx, component.packageKey(), component.Sources(),
+						func(upstream *Upstream) error {
+							return s.uploadImage(ctx, component, repoName, upstream)
+						})
+					if result.Err != nil {
+						log.Errorf("%v", result.Err)
+					}
+				}
+				resultsChan <- result
+				<-limitChan
+			}(v, repoName)
+		} else if config.ComponentType(v.Format).Bundled() {
+			if isUploaded(v.UploadPath()) {
+				continue
+			}
 			// Process assets as a bundle
 			resultsCounter++
 			go func(format config.ComponentType, component *NexusExportComponent, repoName string) {
 				limitChan <- struct{}{}
-				result := &UploadResult{}
-				if err := s.uploadComponent(format, component, repoName); err != nil {
-					log.Errorf("%v", err)
-					result = &UploadResult{Err: err, ComponentPath: component.FullName()}
+				result := &UploadResult{ComponentPath: component.UploadPath()}
+				if ctx.Err() != nil {
+					// Don't start new uploads for canceled request
+					result.Err = ctx.Err()
+				} else {
+					ctx := started(component.UploadPath())
+					result.Source, result.Err = s.uploadFromSources(ctx, component.packageKey(), component.Sources(),
+						func(upstream *Upstream) error {
+							return s.uploadComponent(ctx, format, component, repoName, upstream)
+						})
+					if result.Err != nil {
+						log.Errorf("%v", result.Err)
+					}
 				}
 				resultsChan <- result
 				<-limitChan
@@ -160,33 +288,73 @@ func (s *NexusServer) UploadComponents(nec *NexusExportComponents, repoName stri
 		} else {
 			// Process assets individually
 			for _, vv := range v.Assets {
+				if isUploaded(vv.Path) {
+					continue
+				}
 				resultsCounter++
-				go func(format config.ComponentType, asset *NexusExportComponentAsset, repoName string, src string) {
+				go func(format config.ComponentType, asset *NexusExportComponentAsset, repoName string,
+					component *NexusExportComponent) {
 					limitChan <- struct{}{}
-					result := &UploadResult{}
-					if err := s.uploadAsset(format, asset, repoName, src); err != nil {
-						log.Errorf("%v", err)
-						result = &UploadResult{Err: err, ComponentPath: asset.Path}
+					result := &UploadResult{ComponentPath: asset.Path}
+					if ctx.Err() != nil {
+						// Don't start new uploads for canceled request
+						result.Err = ctx.Err()
+					} else {
+						ctx := started(asset.Path)
+						result.Source, result.Err = s.uploadFromSources(ctx, component.packageKey(), component.Sources(),
+							func(upstream *Upstream) error {
+								return s.uploadAsset(ctx, format, asset, repoName, upstream)
+							})
+						if result.Err != nil {
+							log.Errorf("%v", result.Err)
+						}
 					}
 					resultsChan <- result
 					<-limitChan
-				}(config.ComponentType(v.Format), vv, repoName, v.ArtifactsSource)
+				}(config.ComponentType(v.Format), vv, repoName, v)
 			}
 		}
 	}
 	var results []UploadResult
-	for {
+	// Wait until we've reached the expected amount of results
+	for len(results) < resultsCounter {
 		result := <-resultsChan
 		results = append(results, *result)
-
-		// if we've reached the expected amount of results then stop
-		if len(results) == resultsCounter {
-			break
+		if tracker != nil {
+			tracker.Uploaded(*result)
 		}
 	}
 	return results
 }
 
+// DeleteComponent will delete component by its id from Nexus server
+func (s *NexusServer) DeleteComponent(c *http.Client, id string) error {
+	srvUrl := fmt.Sprintf("%s%s%s/%s", s.Host, s.BaseUrl, s.ApiComponentsUrl, id)
+	req, err := http.NewRequest("DELETE", srvUrl, nil)
+	if err != nil {
+		return fmt.Errorf("DeleteComponent: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(s.Username, s.Password)
+	// Send request
+	resp, err := c.Do(req)
+	if err != nil {
+		return fmt.Errorf("DeleteComponent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusNoContent {
+		return &utils.ContextError{
+			Context: "DeleteComponent",
+			Err: fmt.Errorf("error: sending '%s' request: status code %d %v",
+				resp.Request.Method,
+				resp.StatusCode,
+				resp.Request.URL),
+		}
+	}
+	return nil
+}
+
 func (s *NexusServer) SendRequest(srvUrl string, method string, c *http.Client, b io.Reader) ([]byte, error) {
 	req, err := http.NewRequest(method, srvUrl, b)
 	if err != nil {
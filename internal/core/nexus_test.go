@@ -113,6 +113,170 @@ func Test_filterAssets(t *testing.T) {
 				"MassTransit/5.2.1-develop.1834.bin",
 			},
 		},
+		{
+			name: "Test4_Rubygems",
+			args: args{
+				nc: &NexusComponents{
+					Items: []*NexusComponent{
+						{
+							Version: "1.15.5",
+							Format:  "rubygems",
+							Assets: []*NexusComponentAsset{
+								{
+									Path:   "quick/Marshal.4.8/nokogiri-1.15.5.gemspec.rz",
+									Format: "rubygems",
+								},
+								{
+									Path:   "gems/nokogiri-1.15.5.gem",
+									Format: "rubygems",
+								},
+								{
+									Path:   "gems/nokogiri-1.15.5-x86_64-linux.gem",
+									Format: "rubygems",
+								},
+								{
+									Path:   "specs.4.8.gz",
+									Format: "rubygems",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantAssetsCount: 2,
+			wantPath: []string{"gems/nokogiri-1.15.5.gem",
+				"gems/nokogiri-1.15.5-x86_64-linux.gem",
+			},
+		},
+		{
+			name: "Test5_Apt",
+			args: args{
+				nc: &NexusComponents{
+					Items: []*NexusComponent{
+						{
+							Version: "1.18.0-6",
+							Format:  "apt",
+							Assets: []*NexusComponentAsset{
+								{
+									Path:   "dists/bullseye/Release",
+									Format: "apt",
+								},
+								{
+									Path:   "pool/n/nginx/nginx_1.18.0-6_amd64.deb",
+									Format: "apt",
+								},
+								{
+									Path:   "dists/bullseye/main/binary-amd64/Packages",
+									Format: "apt",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantAssetsCount: 1,
+			wantPath:        []string{"pool/n/nginx/nginx_1.18.0-6_amd64.deb"},
+		},
+		{
+			name: "Test6_Yum",
+			args: args{
+				nc: &NexusComponents{
+					Items: []*NexusComponent{
+						{
+							Version: "4.2.46-34.el7",
+							Format:  "yum",
+							Assets: []*NexusComponentAsset{
+								{
+									Path:   "7/os/x86_64/repodata/repomd.xml",
+									Format: "yum",
+								},
+								{
+									Path:   "7/os/x86_64/Packages/bash-4.2.46-34.el7.x86_64.rpm",
+									Format: "yum",
+								},
+								{
+									Path:   "repodata/primary.xml.gz",
+									Format: "yum",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantAssetsCount: 1,
+			wantPath:        []string{"7/os/x86_64/Packages/bash-4.2.46-34.el7.x86_64.rpm"},
+		},
+		{
+			name: "Test7_Go",
+			args: args{
+				nc: &NexusComponents{
+					Items: []*NexusComponent{
+						{
+							Version: "v0.1.0",
+							Format:  "go",
+							Assets: []*NexusComponentAsset{
+								{
+									Path:   "golang.org/x/sync/@v/list",
+									Format: "go",
+								},
+								{
+									Path:   "golang.org/x/sync/@v/v0.1.0.info",
+									Format: "go",
+								},
+								{
+									Path:   "golang.org/x/sync/@v/v0.1.0.mod",
+									Format: "go",
+								},
+								{
+									Path:   "golang.org/x/sync/@v/v0.1.0.zip",
+									Format: "go",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantAssetsCount: 3,
+			wantPath: []string{"golang.org/x/sync/@v/v0.1.0.info",
+				"golang.org/x/sync/@v/v0.1.0.mod",
+				"golang.org/x/sync/@v/v0.1.0.zip",
+			},
+		},
+		{
+			name: "Test8_Conda",
+			args: args{
+				nc: &NexusComponents{
+					Items: []*NexusComponent{
+						{
+							Version: "1.21.0",
+							Format:  "conda",
+							Assets: []*NexusComponentAsset{
+								{
+									Path:   "linux-64/repodata.json",
+									Format: "conda",
+								},
+								{
+									Path:   "linux-64/numpy-1.21.0-py39h.tar.bz2",
+									Format: "conda",
+								},
+								{
+									Path:   "channeldata.json",
+									Format: "conda",
+								},
+								{
+									Path:   "linux-64/numpy-1.21.0-py310h.conda",
+									Format: "conda",
+								},
+							},
+						},
+					},
+				},
+			},
+			wantAssetsCount: 2,
+			wantPath: []string{"linux-64/numpy-1.21.0-py39h.tar.bz2",
+				"linux-64/numpy-1.21.0-py310h.conda",
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -129,7 +293,7 @@ func Test_filterAssets(t *testing.T) {
 							t.Errorf("filterHashAssets() = %v, want path %v", vv.Path, tt.wantPath)
 						}
 					}
-				case "maven2":
+				case "maven2", "rubygems", "apt", "yum", "go", "conda":
 					if len(v.Assets) != tt.wantAssetsCount {
 						t.Errorf("filterHashAssets() = len(%v), want path len(%v)", len(v.Assets), len(tt.wantPath))
 					}
@@ -1,31 +1,33 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
-	"nexus-pusher/pkg/http_clients"
 	"nexus-pusher/pkg/utils"
 	"strings"
 )
 
 type Npm struct {
 	Server   string
+	Upstream *Upstream
 	Path     string
 	FileName string
 }
 
-func NewNpm(server string, path string, fileName string) *Npm {
+func NewNpm(upstream *Upstream, path string, fileName string) *Npm {
 	return &Npm{
-		Server:   server,
+		Server:   upstream.URL,
+		Upstream: upstream,
 		Path:     path,
 		FileName: fileName,
 	}
 }
 
-func (n Npm) DownloadAsset() (*http.Response, error) {
+func (n Npm) DownloadAsset(ctx context.Context) (*http.Response, error) {
 	// Get NPM component
-	req, err := http.NewRequest("GET", n.assetDownloadURL(), nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", n.assetDownloadURL(), nil)
 	if err != nil {
 		return nil, fmt.Errorf("DownloadAsset: %w", err)
 	}
@@ -33,7 +35,7 @@ func (n Npm) DownloadAsset() (*http.Response, error) {
 	req.Header.Set("Accept", "application/octet-stream")
 
 	// Send request
-	return http_clients.HttpRetryClient(180).Do(req) // Set 3 min timeout to handle files
+	return n.Upstream.do(req, 180) // Set 3 min timeout to handle files
 
 }
 
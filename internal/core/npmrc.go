@@ -0,0 +1,94 @@
+package core
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"nexus-pusher/pkg/utils"
+	"os"
+	"strings"
+)
+
+// readNpmrcAuth returns credentials of npm registry from .npmrc file. Registry credentials are set as
+// '//registry.some/path/:_authToken=<token>', '//registry.some/path/:_auth=<base64 of user:pass>' or
+// 'username' and base64 encoded '_password' pair. Environment variables (i.e. '${NPM_TOKEN}') are expanded
+func readNpmrcAuth(path string, registry string) (*UpstreamAuth, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("readNpmrcAuth: %w", err)
+	}
+	defer f.Close()
+
+	scopes := make(map[string]*UpstreamAuth)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(text, "//") {
+			// Only registry scoped settings hold credentials
+			continue
+		}
+		i := strings.Index(text, "=")
+		if i < 0 {
+			continue
+		}
+		j := strings.LastIndex(text[:i], ":")
+		if j < 0 {
+			continue
+		}
+		scope := strings.TrimRight(text[:j], "/")
+		key := strings.TrimSpace(text[j+1 : i])
+		value := os.ExpandEnv(strings.Trim(strings.TrimSpace(text[i+1:]), "\"'"))
+		if key != "_authToken" && key != "_auth" && key != "username" && key != "_password" {
+			continue
+		}
+		auth, ok := scopes[scope]
+		if !ok {
+			auth = &UpstreamAuth{}
+			scopes[scope] = auth
+		}
+		switch key {
+		case "_authToken":
+			auth.Token = value
+		case "_auth":
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("readNpmrcAuth: '%s:_auth': %w", scope, err)
+			}
+			credentials := strings.SplitN(string(decoded), ":", 2)
+			auth.Username = credentials[0]
+			if len(credentials) == 2 {
+				auth.Password = credentials[1]
+			}
+		case "username":
+			auth.Username = value
+		case "_password":
+			decoded, err := base64.StdEncoding.DecodeString(value)
+			if err != nil {
+				return nil, fmt.Errorf("readNpmrcAuth: '%s:_password': %w", scope, err)
+			}
+			auth.Password = string(decoded)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("readNpmrcAuth: %w", err)
+	}
+
+	// Registry url without scheme is matched with the most specific scope
+	target := strings.TrimRight(registry, "/")
+	if i := strings.Index(target, "//"); i >= 0 {
+		target = target[i:]
+	}
+	var matched string
+	for k := range scopes {
+		if (target == k || strings.HasPrefix(target, k+"/")) && len(k) > len(matched) {
+			matched = k
+		}
+	}
+	if matched == "" {
+		return nil, &utils.ContextError{
+			Context: "readNpmrcAuth",
+			Err:     fmt.Errorf("no credentials of registry %s found in %s", registry, path),
+		}
+	}
+	return scopes[matched], nil
+}
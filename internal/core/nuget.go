@@ -1,13 +1,13 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/goccy/go-json"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"net/url"
-	"nexus-pusher/pkg/http_clients"
 	"nexus-pusher/pkg/utils"
 	"path"
 	"strings"
@@ -15,28 +15,30 @@ import (
 
 type Nuget struct {
 	Server   string
+	Upstream *Upstream
 	FileName string
 	Name     string
 	Version  string
 }
 
-func NewNuget(server string, fileName string, name string, version string) *Nuget {
+func NewNuget(upstream *Upstream, fileName string, name string, version string) *Nuget {
 	return &Nuget{
-		Server:   server,
+		Server:   upstream.URL,
+		Upstream: upstream,
 		FileName: fileName,
 		Name:     name,
 		Version:  version,
 	}
 }
 
-func (n Nuget) DownloadAsset() (*http.Response, error) {
+func (n Nuget) DownloadAsset(ctx context.Context) (*http.Response, error) {
 	// Get NUGET component
-	assetURL, err := n.assetDownloadURL()
+	assetURL, err := n.assetDownloadURL(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("DownloadAsset: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", assetURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("DownloadAsset: %w", err)
 	}
@@ -44,7 +46,7 @@ func (n Nuget) DownloadAsset() (*http.Response, error) {
 	req.Header.Set("Accept", "application/octet-stream")
 
 	// Send request
-	return http_clients.HttpRetryClient(180).Do(req) // Set 3 min timeout to handle files
+	return n.Upstream.do(req, 180) // Set 3 min timeout to handle files
 }
 
 func (n Nuget) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
@@ -62,9 +64,9 @@ func (n Nuget) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
 	return contentType, body
 }
 
-func (n Nuget) assetDownloadURL() (string, error) {
+func (n Nuget) assetDownloadURL(ctx context.Context) (string, error) {
 	// Check base server download url version
-	baseDownloadUrl, err := n.checkVersion()
+	baseDownloadUrl, err := n.checkVersion(ctx)
 	if err != nil {
 		return "", fmt.Errorf("assetDownloadURL: %w", err)
 	}
@@ -90,7 +92,7 @@ func (n Nuget) assetDownloadURL() (string, error) {
 }
 
 //
-func (n Nuget) checkVersion() (string, error) {
+func (n Nuget) checkVersion(ctx context.Context) (string, error) {
 	parsedUrl, err := url.Parse(n.Server)
 	if err != nil {
 		return "", fmt.Errorf("checkVersion: %w", err)
@@ -98,7 +100,7 @@ func (n Nuget) checkVersion() (string, error) {
 
 	// V3 check
 	if path.Base(parsedUrl.Path) == "index.json" {
-		baseDownloadUrl, err := n.baseUrlV3()
+		baseDownloadUrl, err := n.baseUrlV3(ctx)
 		if err != nil {
 			return "", fmt.Errorf("checkVersion: %w", err)
 		}
@@ -110,15 +112,15 @@ func (n Nuget) checkVersion() (string, error) {
 	return n.Server, nil
 }
 
-func (n Nuget) baseUrlV3() (string, error) {
-	req, err := http.NewRequest("GET", n.Server, nil)
+func (n Nuget) baseUrlV3(ctx context.Context) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", n.Server, nil)
 	if err != nil {
 		return "", fmt.Errorf("baseUrlV3: %w", err)
 	}
 	req.Header.Set("Accept", "application/json")
 
 	// Send request
-	resp, err := http_clients.HttpRetryClient().Do(req)
+	resp, err := n.Upstream.do(req)
 	if err != nil {
 		return "", fmt.Errorf("baseUrlV3: %w", err)
 	}
@@ -0,0 +1,525 @@
+package core
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/goccy/go-json"
+	log "github.com/sirupsen/logrus"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/pkg/utils"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// bundleManifestName is a name of manifest file inside offline bundle archive
+const bundleManifestName = "manifest.json"
+
+// OfflineDiff holds components diff for destination repository to be transferred offline
+type OfflineDiff struct {
+	Server     string                  `json:"server"`
+	Repository string                  `json:"repository"`
+	Items      []*NexusExportComponent `json:"items"`
+	// Upstreams is holding credentials of artifacts sources taken from config at export,
+	// they are never written to diff file and bundle manifest
+	Upstreams []*Upstream `json:"-"`
+}
+
+// BundleManifest describes content of offline bundle archive
+type BundleManifest struct {
+	Created time.Time      `json:"created"`
+	Diffs   []*OfflineDiff `json:"diffs"`
+	Files   []*BundleFile  `json:"files"`
+}
+
+// BundleFile describes one downloaded asset inside offline bundle archive
+type BundleFile struct {
+	Name        string `json:"name"`
+	Diff        int    `json:"diff"` // Index of manifest diff which asset belongs to
+	AssetPath   string `json:"assetPath"`
+	DownloadURL string `json:"downloadUrl"`
+	Size        int64  `json:"size"`
+	SHA256      string `json:"sha256"`
+}
+
+// ExportBundle downloads all assets from diffs and writes them to one tar archive with manifest
+func ExportBundle(ctx context.Context, diffs []*OfflineDiff, bundlePath string) ([]UploadResult, error) {
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+	defer f.Close()
+
+	tmpDir, err := ioutil.TempDir("", "nexus-pusher-export")
+	if err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	// Repository indexes of artifacts sources are shared by all components of bundle
+	ctx = withIndexCache(ctx)
+
+	bw := &bundleWriter{tw: tar.NewWriter(f), tmpDir: tmpDir}
+	manifest := &BundleManifest{Created: time.Now()}
+
+	var results []UploadResult
+	for i, diff := range diffs {
+		manifest.Diffs = append(manifest.Diffs, diff)
+
+		for _, v := range bundleRawComponents(diff.Items) {
+			result := UploadResult{ComponentPath: v.UploadPath()}
+			files, err := bw.exportComponent(ctx, v, diff.Upstreams)
+			if err != nil {
+				log.Errorf("%v", err)
+				result.Err = err
+			}
+			for _, f := range files {
+				f.Diff = i
+			}
+			manifest.Files = append(manifest.Files, files...)
+			results = append(results, result)
+		}
+	}
+
+	// Manifest is written last, when all file checksums are known
+	body, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+	if err := bw.tw.WriteHeader(&tar.Header{
+		Name:    bundleManifestName,
+		Mode:    0644,
+		Size:    int64(len(body)),
+		ModTime: manifest.Created,
+	}); err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+	if _, err := bw.tw.Write(body); err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+	if err := bw.tw.Close(); err != nil {
+		return nil, fmt.Errorf("ExportBundle: %w", err)
+	}
+
+	return results, nil
+}
+
+type bundleWriter struct {
+	tw      *tar.Writer
+	tmpDir  string
+	counter int
+}
+
+func (bw *bundleWriter) exportComponent(ctx context.Context, component *NexusExportComponent,
+	upstreams []*Upstream) ([]*BundleFile, error) {
+	// Artifacts sources are tried in order until component is downloaded
+	var failures []*SourceError
+	for _, v := range component.Sources() {
+		responses, err := downloadComponent(ctx, component, resolveUpstream(upstreams, v))
+		if err != nil {
+			failures = append(failures, &SourceError{Source: v, Err: err})
+			if ctx.Err() != nil {
+				break
+			}
+			continue
+		}
+		files, err := bw.writeFiles(component, responses)
+		closeResponses(responses)
+		if err != nil {
+			return nil, fmt.Errorf("exportComponent: %w", err)
+		}
+		return files, nil
+	}
+	if len(failures) == 1 {
+		return nil, fmt.Errorf("exportComponent: %w", failures[0].Err)
+	}
+	return nil, fmt.Errorf("exportComponent: %w", &SourcesError{Errors: failures})
+}
+
+// downloadComponent will download all component assets from upstream
+func downloadComponent(ctx context.Context, component *NexusExportComponent,
+	upstream *Upstream) ([]*http.Response, error) {
+	format := config.ComponentType(component.Format)
+
+	var responses []*http.Response
+	if format.Bundled() {
+		c, err := newComponenter(format, component, upstream)
+		if err != nil {
+			return nil, fmt.Errorf("downloadComponent: %w", err)
+		}
+		if responses, err = sourceComponenter(c, component.Assets, upstream).DownloadComponent(ctx); err != nil {
+			return nil, fmt.Errorf("downloadComponent: %w", err)
+		}
+	} else {
+		for _, asset := range component.Assets {
+			a, err := newAsseter(format, asset, upstream)
+			if err != nil {
+				closeResponses(responses)
+				return nil, fmt.Errorf("downloadComponent: %w", err)
+			}
+			resp, err := sourceAsseter(a, asset, upstream).DownloadAsset(ctx)
+			if err != nil {
+				closeResponses(responses)
+				return nil, fmt.Errorf("downloadComponent: %w", err)
+			}
+			responses = append(responses, resp)
+		}
+	}
+
+	for _, resp := range responses {
+		if resp.StatusCode != http.StatusOK {
+			closeResponses(responses)
+			return nil, &utils.ContextError{
+				Context: "downloadComponent",
+				Err: fmt.Errorf("unable to download asset. sending '%s' request: status code %d %v",
+					resp.Request.Method,
+					resp.StatusCode,
+					resp.Request.URL),
+			}
+		}
+	}
+	return responses, nil
+}
+
+// writeFiles adds downloaded component assets to archive
+func (bw *bundleWriter) writeFiles(component *NexusExportComponent, responses []*http.Response) ([]*BundleFile, error) {
+	files := make([]*BundleFile, 0, len(responses))
+	for i, resp := range responses {
+		file, err := bw.writeFile(component.Assets[i].Path, resp)
+		if err != nil {
+			return nil, fmt.Errorf("writeFiles: %w", err)
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// writeFile stores response body in temporary file to get its size and checksum, then adds it to archive
+func (bw *bundleWriter) writeFile(assetPath string, resp *http.Response) (*BundleFile, error) {
+	bw.counter++
+	file := &BundleFile{
+		Name:        fmt.Sprintf("files/%06d", bw.counter),
+		AssetPath:   assetPath,
+		DownloadURL: resp.Request.URL.String(),
+	}
+
+	tmp, err := os.Create(filepath.Join(bw.tmpDir, filepath.Base(file.Name)))
+	if err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	h := sha256.New()
+	if file.Size, err = io.Copy(io.MultiWriter(tmp, h), resp.Body); err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+	file.SHA256 = hex.EncodeToString(h.Sum(nil))
+
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+	if err := bw.tw.WriteHeader(&tar.Header{
+		Name:    file.Name,
+		Mode:    0644,
+		Size:    file.Size,
+		ModTime: time.Now(),
+	}); err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+	if _, err := io.Copy(bw.tw, tmp); err != nil {
+		return nil, fmt.Errorf("writeFile: %w", err)
+	}
+
+	log.Debugf("Asset %s exported to bundle as %s", assetPath, file.Name)
+	return file, nil
+}
+
+// ReadBundle extracts bundle archive to directory and verifies every file with manifest checksums.
+// Only files listed in manifest are accepted, archive paths are not trusted and files are stored by base name
+func ReadBundle(bundlePath string, dir string) (*BundleManifest, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("ReadBundle: %w", err)
+	}
+	defer f.Close()
+
+	// extracted is holding archive name of every file stored by base name
+	extracted := make(map[string]string)
+	var manifest *BundleManifest
+
+	tr := tar.NewReader(f)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("ReadBundle: %w", err)
+		}
+
+		if hdr.Name == bundleManifestName {
+			if manifest != nil {
+				return nil, &utils.ContextError{
+					Context: "ReadBundle",
+					Err:     fmt.Errorf("error: duplicate '%s' in bundle %s", bundleManifestName, bundlePath),
+				}
+			}
+			body, err := ioutil.ReadAll(tr)
+			if err != nil {
+				return nil, fmt.Errorf("ReadBundle: %w", err)
+			}
+			manifest = &BundleManifest{}
+			if err := json.Unmarshal(body, manifest); err != nil {
+				return nil, fmt.Errorf("ReadBundle: %w", err)
+			}
+			continue
+		}
+
+		baseName := filepath.Base(hdr.Name)
+		if name, ok := extracted[baseName]; ok {
+			return nil, &utils.ContextError{
+				Context: "ReadBundle",
+				Err:     fmt.Errorf("error: files %s and %s have the same name in bundle %s", name, hdr.Name, bundlePath),
+			}
+		}
+		extracted[baseName] = hdr.Name
+		if err := extractFile(tr, filepath.Join(dir, baseName)); err != nil {
+			return nil, fmt.Errorf("ReadBundle: %w", err)
+		}
+	}
+
+	if manifest == nil {
+		return nil, &utils.ContextError{
+			Context: "ReadBundle",
+			Err:     fmt.Errorf("error: '%s' not found in bundle %s", bundleManifestName, bundlePath),
+		}
+	}
+
+	if err := verifyBundleFiles(manifest, extracted, dir); err != nil {
+		return nil, fmt.Errorf("ReadBundle: %w", err)
+	}
+	return manifest, nil
+}
+
+// verifyBundleFiles check extracted files are exactly the files of manifest and their content on disk
+// matches manifest checksums
+func verifyBundleFiles(manifest *BundleManifest, extracted map[string]string, dir string) error {
+	listed := make(map[string]bool, len(manifest.Files))
+	for _, v := range manifest.Files {
+		baseName := filepath.Base(v.Name)
+		if listed[baseName] || extracted[baseName] != v.Name {
+			return &utils.ContextError{
+				Context: "verifyBundleFiles",
+				Err:     fmt.Errorf("error: file %s (asset %s) is missing or duplicated in bundle", v.Name, v.AssetPath),
+			}
+		}
+		listed[baseName] = true
+
+		checksum, err := fileSHA256(filepath.Join(dir, baseName))
+		if err != nil {
+			return fmt.Errorf("verifyBundleFiles: %w", err)
+		}
+		if checksum != v.SHA256 {
+			return &utils.ContextError{
+				Context: "verifyBundleFiles",
+				Err: fmt.Errorf("error: checksum mismatch for file %s (asset %s). want: %s, get: %s",
+					v.Name, v.AssetPath, v.SHA256, checksum),
+			}
+		}
+	}
+
+	for baseName, name := range extracted {
+		if !listed[baseName] {
+			return &utils.ContextError{
+				Context: "verifyBundleFiles",
+				Err:     fmt.Errorf("error: file %s is not listed in bundle manifest", name),
+			}
+		}
+	}
+	return nil
+}
+
+func extractFile(r io.Reader, fileName string) error {
+	f, err := os.Create(fileName)
+	if err != nil {
+		return fmt.Errorf("extractFile: %w", err)
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil {
+		return fmt.Errorf("extractFile: %w", err)
+	}
+	return nil
+}
+
+func fileSHA256(fileName string) (string, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return "", fmt.Errorf("fileSHA256: %w", err)
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", fmt.Errorf("fileSHA256: %w", err)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ImportBundle uploads components of extracted bundle diff to destination repository, diff must be one of manifest diffs
+func (s *NexusServer) ImportBundle(ctx context.Context, manifest *BundleManifest, diff *OfflineDiff, dir string) []UploadResult {
+	// Assets of different diffs may have the same path, so only files of this diff are used
+	index := -1
+	for i, v := range manifest.Diffs {
+		if v == diff {
+			index = i
+		}
+	}
+	files := make(map[string]*BundleFile)
+	for _, v := range manifest.Files {
+		if v.Diff == index {
+			files[v.AssetPath] = v
+		}
+	}
+
+	var results []UploadResult
+	for _, v := range bundleRawComponents(diff.Items) {
+		result := UploadResult{ComponentPath: v.UploadPath()}
+		if err := s.importComponent(ctx, v, diff.Repository, files, dir); err != nil {
+			log.Errorf("%v", err)
+			result.Err = err
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+func (s *NexusServer) importComponent(ctx context.Context, component *NexusExportComponent, repoName string,
+	files map[string]*BundleFile, dir string) error {
+	format := config.ComponentType(component.Format)
+
+	// Restore downloaded responses from bundle files
+	responses := make([]*http.Response, 0, len(component.Assets))
+	defer func() {
+		closeResponses(responses)
+	}()
+	for _, asset := range component.Assets {
+		file, ok := files[asset.Path]
+		if !ok {
+			return &utils.ContextError{
+				Context: "importComponent",
+				Err:     fmt.Errorf("error: asset %s was not exported to bundle", asset.Path),
+			}
+		}
+		resp, err := bundleFileResponse(file, dir)
+		if err != nil {
+			return fmt.Errorf("importComponent: %w", err)
+		}
+		responses = append(responses, resp)
+	}
+
+	if format.Bundled() {
+		c, err := newComponenter(format, component, s.upstream(component.ArtifactsSource))
+		if err != nil {
+			return fmt.Errorf("importComponent: %w", err)
+		}
+		contentType, uploadBody := c.PrepareComponentToUpload(responses)
+		if err := s.uploadComponentWithType(ctx, repoName, component.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("importComponent: %w", err)
+		}
+		return nil
+	}
+
+	for i, asset := range component.Assets {
+		a, err := newAsseter(format, asset, s.upstream(component.ArtifactsSource))
+		if err != nil {
+			return fmt.Errorf("importComponent: %w", err)
+		}
+		contentType, uploadBody := a.PrepareAssetToUpload(responses[i].Body)
+		if conda, ok := a.(*Conda); ok {
+			err = s.uploadAssetWithPut(ctx, repoName, conda.subdirPath(), contentType, uploadBody)
+		} else {
+			err = s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody)
+		}
+		if err != nil {
+			return fmt.Errorf("importComponent: %w", err)
+		}
+	}
+	return nil
+}
+
+// bundleFileResponse returns bundle file as download response to reuse format specific upload logic
+func bundleFileResponse(file *BundleFile, dir string) (*http.Response, error) {
+	downloadURL, err := url.Parse(file.DownloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("bundleFileResponse: %w", err)
+	}
+	f, err := os.Open(filepath.Join(dir, filepath.Base(file.Name)))
+	if err != nil {
+		return nil, fmt.Errorf("bundleFileResponse: %w", err)
+	}
+	return &http.Response{
+		StatusCode:    http.StatusOK,
+		Body:          f,
+		ContentLength: file.Size,
+		Request:       &http.Request{Method: "GET", URL: downloadURL},
+	}, nil
+}
+
+func closeResponses(responses []*http.Response) {
+	for _, resp := range responses {
+		resp.Body.Close()
+	}
+}
+
+// newAsseter returns format specific handler for individually processed asset
+func newAsseter(format config.ComponentType, asset *NexusExportComponentAsset,
+	upstream *Upstream) (config.Asseter, error) {
+	switch format.Lower() {
+	case config.NPM:
+		return NewNpm(upstream, asset.Path, asset.FileName), nil
+	case config.PYPI:
+		return NewPypi(upstream, asset.Path, asset.FileName, asset.Name, asset.Version), nil
+	case config.NUGET:
+		return NewNuget(upstream, asset.FileName, asset.Name, asset.Version), nil
+	case config.HELM:
+		return NewHelm(upstream, asset.FileName, asset.Name, asset.Version), nil
+	case config.RUBY:
+		return NewRubygems(upstream, asset.FileName), nil
+	case config.APT:
+		return NewApt(upstream, asset.Path, asset.FileName), nil
+	case config.YUM:
+		return NewYum(upstream, asset.Path, asset.FileName), nil
+	case config.CONDA:
+		return NewConda(upstream, asset.Path, asset.FileName), nil
+	default:
+		return nil, &utils.ContextError{
+			Context: "newAsseter",
+			Err:     fmt.Errorf("component type %s is not supported for offline transfer", format),
+		}
+	}
+}
+
+// newComponenter returns format specific handler for bundled component
+func newComponenter(format config.ComponentType, component *NexusExportComponent,
+	upstream *Upstream) (config.Componenter, error) {
+	switch format.Lower() {
+	case config.MAVEN2:
+		return NewMaven2(upstream, component), nil
+	case config.RAW:
+		return NewRaw(upstream, component), nil
+	default:
+		return nil, &utils.ContextError{
+			Context: "newComponenter",
+			Err:     fmt.Errorf("component type %s is not supported for offline transfer", format),
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package core
+
+import (
+	"archive/tar"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/goccy/go-json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestOfflineBundle(t *testing.T) {
+	const assetData = "npm-package-data"
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if r.URL.Path != "/pkg/-/pkg-1.0.0.tgz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if _, err := w.Write([]byte(assetData)); err != nil {
+			t.Error(err)
+		}
+	}))
+	defer upstream.Close()
+
+	var mu sync.Mutex
+	var uploaded []string
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		uploaded = append(uploaded, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer nexus.Close()
+
+	diffs := []*OfflineDiff{{
+		Server:     nexus.URL,
+		Repository: "npm-repo",
+		Items: []*NexusExportComponent{{
+			Name:            "pkg",
+			Version:         "1.0.0",
+			Format:          "npm",
+			ArtifactsSource: upstream.URL + "/",
+			Assets: []*NexusExportComponentAsset{{
+				Name:     "pkg",
+				Version:  "1.0.0",
+				FileName: "pkg-1.0.0.tgz",
+				Path:     "pkg/-/pkg-1.0.0.tgz",
+			}},
+		}},
+		Upstreams: []*Upstream{{URL: upstream.URL, Auth: &UpstreamAuth{Token: "secret"}}},
+	}}
+
+	dir, err := ioutil.TempDir("", "offline-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	bundlePath := filepath.Join(dir, "bundle.tar")
+
+	results, err := ExportBundle(context.Background(), diffs, bundlePath)
+	if err != nil {
+		t.Fatalf("ExportBundle() error = %v", err)
+	}
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("ExportBundle() results = %v", results)
+	}
+
+	extractDir := filepath.Join(dir, "extract")
+	if err := os.Mkdir(extractDir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := ReadBundle(bundlePath, extractDir)
+	if err != nil {
+		t.Fatalf("ReadBundle() error = %v", err)
+	}
+	if len(manifest.Files) != 1 || manifest.Files[0].Size != int64(len(assetData)) {
+		t.Fatalf("ReadBundle() files = %v", manifest.Files)
+	}
+	if manifest.Diffs[0].Upstreams != nil {
+		t.Fatalf("ReadBundle() upstream credentials must not be written to bundle")
+	}
+
+	s := NewNexusServer("user", "pass", nexus.URL, "/service/rest", "/v1/components")
+	results = s.ImportBundle(context.Background(), manifest, manifest.Diffs[0], extractDir)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("ImportBundle() results = %v", results)
+	}
+	if len(uploaded) != 1 || !strings.Contains(uploaded[0], assetData) ||
+		!strings.Contains(uploaded[0], "npm.asset") {
+		t.Errorf("ImportBundle() uploaded = %v", uploaded)
+	}
+
+	// Bundle without manifest must be rejected
+	if err := ioutil.WriteFile(filepath.Join(extractDir, "broken"), nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadBundle(filepath.Join(extractDir, "broken"), extractDir); err == nil {
+		t.Errorf("ReadBundle() must fail for bundle without manifest")
+	}
+}
+
+func TestReadBundle(t *testing.T) {
+	checksum := func(data string) string {
+		h := sha256.Sum256([]byte(data))
+		return hex.EncodeToString(h[:])
+	}
+	manifest := &BundleManifest{Files: []*BundleFile{
+		{Name: "files/000001", AssetPath: "pkg/-/pkg-1.0.0.tgz", SHA256: checksum("first")},
+	}}
+	type entry struct {
+		name string
+		data string
+	}
+	tests := []struct {
+		name    string
+		entries []entry
+		wantErr bool
+	}{
+		{"listed file", []entry{{"files/000001", "first"}}, false},
+		{"checksum mismatch", []entry{{"files/000001", "second"}}, true},
+		{"missing file", nil, true},
+		{"file is not in manifest", []entry{{"files/000001", "first"}, {"files/000002", "second"}}, true},
+		// Second file would overwrite verified one on disk
+		{"duplicate base name", []entry{{"files/000001", "first"}, {"other/000001", "second"}}, true},
+		{"listed file at other path", []entry{{"other/000001", "first"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir, err := ioutil.TempDir("", "offline-test")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+
+			f, err := os.Create(filepath.Join(dir, "bundle.tar"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			tw := tar.NewWriter(f)
+			body, err := json.Marshal(manifest)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, v := range append(tt.entries, entry{bundleManifestName, string(body)}) {
+				if err := tw.WriteHeader(&tar.Header{Name: v.name, Mode: 0644, Size: int64(len(v.data))}); err != nil {
+					t.Fatal(err)
+				}
+				if _, err := tw.Write([]byte(v.data)); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := tw.Close(); err != nil {
+				t.Fatal(err)
+			}
+			f.Close()
+
+			if _, err := ReadBundle(f.Name(), dir); (err != nil) != tt.wantErr {
+				t.Errorf("ReadBundle() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestNexusServer_ImportBundle_samePathInDiffs(t *testing.T) {
+	var uploaded []string
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		uploaded = append(uploaded, string(body))
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer nexus.Close()
+
+	dir, err := ioutil.TempDir("", "offline-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	const assetPath = "pkg/-/pkg-1.0.0.tgz"
+	diff := func(repo string) *OfflineDiff {
+		return &OfflineDiff{Server: nexus.URL, Repository: repo, Items: []*NexusExportComponent{{
+			Name: "pkg", Version: "1.0.0", Format: "npm",
+			Assets: []*NexusExportComponentAsset{{Name: "pkg", Version: "1.0.0", FileName: "pkg-1.0.0.tgz", Path: assetPath}},
+		}}}
+	}
+	manifest := &BundleManifest{Diffs: []*OfflineDiff{diff("npm-repo1"), diff("npm-repo2")}}
+	for i, data := range []string{"first-data", "second-data"} {
+		file := &BundleFile{Name: fmt.Sprintf("files/%06d", i+1), Diff: i, AssetPath: assetPath, Size: int64(len(data))}
+		if err := ioutil.WriteFile(filepath.Join(dir, filepath.Base(file.Name)), []byte(data), 0600); err != nil {
+			t.Fatal(err)
+		}
+		manifest.Files = append(manifest.Files, file)
+	}
+
+	s := NewNexusServer("user", "pass", nexus.URL, "/service/rest", "/v1/components")
+	results := s.ImportBundle(context.Background(), manifest, manifest.Diffs[1], dir)
+	if len(results) != 1 || results[0].Err != nil {
+		t.Fatalf("ImportBundle() results = %v", results)
+	}
+	if len(uploaded) != 1 || !strings.Contains(uploaded[0], "second-data") {
+		t.Errorf("ImportBundle() uploaded = %v, want asset of second diff", uploaded)
+	}
+}
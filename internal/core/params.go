@@ -1,6 +1,9 @@
 package core
 
 import (
+	"crypto/tls"
+	"fmt"
+	"nexus-pusher/internal/config"
 	"strings"
 	"time"
 )
@@ -11,6 +14,15 @@ type NexusServer struct {
 	ApiComponentsUrl string
 	Username         string
 	Password         string
+	DockerConnector  string
+	// Profile is a name of server-side destination profile holding host credentials
+	Profile string
+	// TLS is holding connection options of server, they are never sent to nexus-pusher server
+	TLS *tls.Config `json:"-"`
+	// Upstreams is holding connection options of artifacts sources
+	Upstreams []*Upstream `json:"-"`
+	// Sources is remembering artifacts sources which served packages, nil to always keep sources order
+	Sources *SourceHistory `json:"-"`
 }
 
 func NewNexusServer(user string, pass string, host string, baseUrl string, apiComponentsUrl string) *NexusServer {
@@ -47,6 +59,8 @@ type (
 		Repository  string `json:"repository"`
 		Format      string `json:"format"`
 		Checksum    struct {
+			Sha1            string `json:"sha1"`
+			Sha256          string `json:"sha256"`
 			AdditionalProp1 struct {
 			} `json:"additionalProp1"`
 			AdditionalProp2 struct {
@@ -71,7 +85,31 @@ func (nca NexusComponentAsset) AssetPathWithoutTrailingZeroes() string {
 	return resultPath
 }
 
+// ComparisonKey returns key to match the same assets between two repositories.
+// Docker manifests are matched by tag and digest to find re-pushed tags.
+func (nca NexusComponentAsset) ComparisonKey() string {
+	if nca.Format == config.DOCKER.String() {
+		return strings.ToLower(fmt.Sprintf("%s@%s", nca.Path, nca.Checksum.Sha256))
+	}
+	return strings.ToLower(nca.AssetPathWithoutTrailingZeroes())
+}
+
 type UploadResult struct {
 	ComponentPath string
-	Err           error
+	// Source is artifacts source which served component, failure reason of every tried source is set to Err
+	Source string
+	Err    error
+}
+
+// UploadTracker is used to follow components upload progress.
+// Started and Transferred are called concurrently by upload workers
+type UploadTracker interface {
+	// IsUploaded reports whether component (or asset) with path was already uploaded
+	IsUploaded(path string) bool
+	// Started is called when component (or asset) upload is started
+	Started(path string)
+	// Transferred is called with count of bytes downloaded from upstream
+	Transferred(n int64)
+	// Uploaded is called with result of every finished component (or asset) upload
+	Uploaded(result UploadResult)
 }
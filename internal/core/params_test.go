@@ -13,6 +13,8 @@ func TestNexusComponentAsset_removeTrailingZeroFromPath(t *testing.T) {
 		Repository  string
 		Format      string
 		Checksum    struct {
+			Sha1            string `json:"sha1"`
+			Sha256          string `json:"sha256"`
 			AdditionalProp1 struct {
 			} `json:"additionalProp1"`
 			AdditionalProp2 struct {
@@ -72,3 +74,31 @@ func TestNexusComponentAsset_removeTrailingZeroFromPath(t *testing.T) {
 		})
 	}
 }
+
+func TestNexusComponentAsset_ComparisonKey(t *testing.T) {
+	dockerAsset := NexusComponentAsset{Path: "v2/Library/nginx/manifests/1.0", Format: "docker"}
+	dockerAsset.Checksum.Sha256 = "ABC"
+	tests := []struct {
+		name  string
+		asset NexusComponentAsset
+		want  string
+	}{
+		{
+			name:  "test1",
+			asset: NexusComponentAsset{Path: "Abp.EntityFrameworkCore/6.5.0", Format: "nuget"},
+			want:  "abp.entityframeworkcore/6.5",
+		},
+		{
+			name:  "test2",
+			asset: dockerAsset,
+			want:  "v2/library/nginx/manifests/1.0@abc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.asset.ComparisonKey(); got != tt.want {
+				t.Errorf("ComparisonKey() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
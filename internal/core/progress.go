@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"io"
+	"nexus-pusher/internal/config"
+)
+
+// transferCounterKey is a context key of downloaded bytes counter
+type transferCounterKey struct{}
+
+// withTransferCounter returns context with counter which is called for every chunk of downloaded data
+func withTransferCounter(ctx context.Context, counter func(n int64)) context.Context {
+	return context.WithValue(ctx, transferCounterKey{}, counter)
+}
+
+// countTransferred wraps response body to report downloaded bytes to context counter
+func countTransferred(ctx context.Context, body io.ReadCloser) io.ReadCloser {
+	counter, ok := ctx.Value(transferCounterKey{}).(func(n int64))
+	if !ok {
+		return body
+	}
+	return &countingReadCloser{ReadCloser: body, counter: counter}
+}
+
+type countingReadCloser struct {
+	io.ReadCloser
+	counter func(n int64)
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	if n > 0 {
+		c.counter(int64(n))
+	}
+	return n, err
+}
+
+// UploadPaths returns paths of all components (or assets) which are uploaded separately by UploadComponents
+func UploadPaths(nec *NexusExportComponents) []string {
+	var paths []string
+	for _, v := range bundleRawComponents(nec.Items) {
+		format := config.ComponentType(v.Format)
+		if format.Lower() == config.DOCKER || format.Bundled() {
+			paths = append(paths, v.UploadPath())
+			continue
+		}
+		for _, vv := range v.Assets {
+			paths = append(paths, vv.Path)
+		}
+	}
+	return paths
+}
@@ -1,27 +1,29 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	"github.com/goccy/go-json"
 	"io"
 	"io/ioutil"
 	"net/http"
-	"nexus-pusher/pkg/http_clients"
 	"nexus-pusher/pkg/utils"
 	"strings"
 )
 
 type Pypi struct {
 	Server   string
+	Upstream *Upstream
 	Path     string
 	FileName string
 	Name     string
 	Version  string
 }
 
-func NewPypi(server string, path string, fileName string, name string, version string) *Pypi {
+func NewPypi(upstream *Upstream, path string, fileName string, name string, version string) *Pypi {
 	return &Pypi{
-		Server:   server,
+		Server:   upstream.URL,
+		Upstream: upstream,
 		Path:     path,
 		FileName: fileName,
 		Name:     name,
@@ -29,14 +31,14 @@ func NewPypi(server string, path string, fileName string, name string, version s
 	}
 }
 
-func (p Pypi) DownloadAsset() (*http.Response, error) {
+func (p Pypi) DownloadAsset(ctx context.Context) (*http.Response, error) {
 	// Get PYPI component
-	assetURL, err := p.assetDownloadURL()
+	assetURL, err := p.assetDownloadURL(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("DownloadAsset: %w", err)
 	}
 
-	req, err := http.NewRequest("GET", assetURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
 	if err != nil {
 		return nil, fmt.Errorf("DownloadAsset: %w", err)
 	}
@@ -44,7 +46,7 @@ func (p Pypi) DownloadAsset() (*http.Response, error) {
 	req.Header.Set("Accept", "application/octet-stream")
 
 	// Send request
-	return http_clients.HttpRetryClient(900).Do(req) // Set 15 min timeout to handle large files
+	return p.Upstream.do(req, 900) // Set 15 min timeout to handle large files
 }
 
 func (p *Pypi) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
@@ -62,11 +64,11 @@ func (p *Pypi) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
 	return contentType, body
 }
 
-func (p Pypi) assetDownloadURL() (string, error) {
+func (p Pypi) assetDownloadURL(ctx context.Context) (string, error) {
 	// Assemble initial request url to get asset version json
 	requestURL := fmt.Sprintf("%spypi/%s/%s/json", p.Server, p.Name, p.Version)
 
-	req, err := http.NewRequest("GET", requestURL, nil)
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
 	if err != nil {
 		return "", fmt.Errorf("assetDownloadURL: %w", err)
 	}
@@ -74,7 +76,7 @@ func (p Pypi) assetDownloadURL() (string, error) {
 	req.Header.Set("Accept", "application/json")
 
 	// Send request
-	resp, err := http_clients.HttpRetryClient().Do(req)
+	resp, err := p.Upstream.do(req)
 	if err != nil {
 		return "", fmt.Errorf("assetDownloadURL: %w", err)
 	}
@@ -0,0 +1,153 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/pkg/utils"
+	"path"
+	"strings"
+)
+
+// rawBundleMaxAssets limits count of assets uploaded with one multipart request
+const rawBundleMaxAssets = 20
+
+type Raw struct {
+	Server    string
+	Upstream  *Upstream
+	Component *NexusExportComponent
+}
+
+func NewRaw(upstream *Upstream, component *NexusExportComponent) *Raw {
+	return &Raw{
+		Server:    upstream.URL,
+		Upstream:  upstream,
+		Component: component,
+	}
+}
+
+func (r Raw) DownloadComponent(ctx context.Context) ([]*http.Response, error) {
+	// Allocate slice for responses following assets count
+	responses := make([]*http.Response, 0, len(r.Component.Assets))
+
+	for i := range r.Component.Assets {
+		req, err := http.NewRequestWithContext(ctx, "GET", r.assetDownloadURL(i), nil)
+		if err != nil {
+			return nil, fmt.Errorf("DownloadComponent: %w", err)
+		}
+		req.Header.Set("Accept", "application/octet-stream")
+
+		// Send request
+		resp, err := r.Upstream.do(req, 900) // Set 15 min timeout to handle large files
+		if err != nil {
+			// Close already opened responses
+			for _, v := range responses {
+				v.Body.Close()
+			}
+			return nil, fmt.Errorf("DownloadComponent: %w", err)
+		}
+
+		responses = append(responses, resp)
+	}
+	// Return all responses
+	return responses, nil
+}
+
+func (r *Raw) PrepareComponentToUpload(responses []*http.Response) (string, io.Reader) {
+	// Create random boundary id
+	boundary := utils.GenRandomBoundary(32)
+	// Create slice of reader with length of responses
+	// multiplied by 3 (format + binary data + closer) plus
+	// two elements - directory field and bodyBottom closer
+	readers := make([]io.Reader, 0, len(responses)*3+2)
+
+	const fileHeader = "Content-type: application/octet-stream"
+	const fileFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\n%s\r\n\r\n"
+	const fieldFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"\r\n\r\n%s\r\n"
+	const fileTypeFormat = "raw.asset%d"
+	const fileNameFormat = "%s.filename"
+	const fieldDirectory = "raw.directory"
+
+	// All assets in bundle share the same directory
+	directoryPart := fmt.Sprintf(fieldFormat, boundary, fieldDirectory, r.directory())
+	readers = append(readers, strings.NewReader(directoryPart))
+
+	// Iterate over all assets and form resulting body
+	for i, resp := range responses {
+		// Setup raw asset index (starting from 1) field
+		fileTypeFormat := fmt.Sprintf(fileTypeFormat, i+1)
+		// Setup raw asset filename field
+		fileNameFormat := fmt.Sprintf(fileNameFormat, fileTypeFormat)
+
+		fileName := r.Component.Assets[i].FileName
+
+		// Generate filename part
+		fileNamePart := fmt.Sprintf(fieldFormat, boundary, fileNameFormat, fileName)
+		// Generate file part
+		filePart := fmt.Sprintf(fileFormat, boundary, fileTypeFormat, fileName, fileHeader)
+		readers = append(readers, strings.NewReader(fileNamePart+filePart), resp.Body, strings.NewReader("\r\n"))
+	}
+
+	// Close boundary
+	bodyBottom := fmt.Sprintf("--%s--\r\n", boundary)
+	readers = append(readers, strings.NewReader(bodyBottom))
+
+	// Form body for http request
+	body := io.MultiReader(readers...)
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return contentType, body
+}
+
+func (r Raw) assetDownloadURL(index int) string {
+	return fmt.Sprintf("%s/%s", removeLastSlash(r.Server), strings.TrimPrefix(r.Component.Assets[index].Path, "/"))
+}
+
+// directory returns bundle directory relative to repository root
+func (r Raw) directory() string {
+	return rawAssetDirectory(r.Component.Assets[0].Path)
+}
+
+func rawAssetDirectory(assetPath string) string {
+	dir := path.Dir(strings.TrimPrefix(assetPath, "/"))
+	if dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+// bundleRawComponents merge raw assets which share the same directory to one
+// component, so they can be uploaded with one request. Other formats are left as is
+func bundleRawComponents(items []*NexusExportComponent) []*NexusExportComponent {
+	bundled := make([]*NexusExportComponent, 0, len(items))
+	directories := make(map[string]*NexusExportComponent)
+	chunks := make(map[string]int)
+	for _, v := range items {
+		if config.ComponentType(v.Format).Lower() != config.RAW {
+			bundled = append(bundled, v)
+			continue
+		}
+		for _, asset := range v.Assets {
+			dir := rawAssetDirectory(asset.Path)
+			bundle, ok := directories[dir]
+			// Start new bundle if there is no one or current is full
+			if !ok || len(bundle.Assets) == rawBundleMaxAssets {
+				bundle = &NexusExportComponent{
+					Name:            dir,
+					Repository:      v.Repository,
+					Format:          v.Format,
+					Group:           v.Group,
+					ArtifactsSource: v.ArtifactsSource,
+					FallbackSources: v.FallbackSources,
+					chunk:           chunks[dir],
+				}
+				chunks[dir]++
+				directories[dir] = bundle
+				bundled = append(bundled, bundle)
+			}
+			bundle.Assets = append(bundle.Assets, asset)
+		}
+	}
+	return bundled
+}
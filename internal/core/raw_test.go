@@ -0,0 +1,74 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func Test_rawAssetDirectory(t *testing.T) {
+	tests := []struct {
+		name      string
+		assetPath string
+		want      string
+	}{
+		{name: "test1", assetPath: "tools/linux/app-1.0.tar.gz", want: "tools/linux"},
+		{name: "test2", assetPath: "/tools/app-1.0.tar.gz", want: "tools"},
+		{name: "test3", assetPath: "app-1.0.tar.gz", want: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rawAssetDirectory(tt.assetPath); got != tt.want {
+				t.Errorf("rawAssetDirectory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_bundleRawComponents(t *testing.T) {
+	asset1 := &NexusExportComponentAsset{Path: "tools/app-1.0.tar.gz"}
+	asset2 := &NexusExportComponentAsset{Path: "tools/app-2.0.tar.gz"}
+	asset3 := &NexusExportComponentAsset{Path: "installers/app.msi"}
+	npmComponent := &NexusExportComponent{Name: "npm1", Format: "npm"}
+
+	items := []*NexusExportComponent{
+		{Name: "tools/app-1.0.tar.gz", Format: "raw", ArtifactsSource: "src", Assets: []*NexusExportComponentAsset{asset1}},
+		npmComponent,
+		{Name: "installers/app.msi", Format: "raw", ArtifactsSource: "src", Assets: []*NexusExportComponentAsset{asset3}},
+		{Name: "tools/app-2.0.tar.gz", Format: "raw", ArtifactsSource: "src", Assets: []*NexusExportComponentAsset{asset2}},
+	}
+	want := []*NexusExportComponent{
+		{Name: "tools", Format: "raw", ArtifactsSource: "src", Assets: []*NexusExportComponentAsset{asset1, asset2}},
+		npmComponent,
+		{Name: "installers", Format: "raw", ArtifactsSource: "src", Assets: []*NexusExportComponentAsset{asset3}},
+	}
+	if got := bundleRawComponents(items); !reflect.DeepEqual(got, want) {
+		t.Errorf("bundleRawComponents() = %v, want %v", got, want)
+	}
+
+	// Check bundle size limit
+	var bigItems []*NexusExportComponent
+	for i := 0; i < rawBundleMaxAssets+1; i++ {
+		bigItems = append(bigItems, &NexusExportComponent{Format: "raw", Assets: []*NexusExportComponentAsset{
+			{Path: fmt.Sprintf("tools/app-%d.tar.gz", i)}}})
+	}
+	if got := bundleRawComponents(bigItems); len(got) != 2 || len(got[0].Assets) != rawBundleMaxAssets {
+		t.Errorf("bundleRawComponents() = %d bundles, want 2 bundles", len(got))
+	}
+}
+
+func TestUploadPaths(t *testing.T) {
+	var assets []*NexusExportComponentAsset
+	for i := 0; i < rawBundleMaxAssets+1; i++ {
+		assets = append(assets, &NexusExportComponentAsset{Path: fmt.Sprintf("tools/app-%d.tar.gz", i)})
+	}
+	nec := &NexusExportComponents{Items: []*NexusExportComponent{
+		{Name: "tools", Format: "raw", Assets: assets},
+		{Name: "core", Version: "1.0", Group: "org.first", Format: "maven2"},
+		{Name: "core", Version: "1.0", Group: "org.second", Format: "maven2"},
+	}}
+	want := []string{"tools", "tools#1", "org.first:core@1.0", "org.second:core@1.0"}
+	if got := UploadPaths(nec); !reflect.DeepEqual(got, want) {
+		t.Errorf("UploadPaths() = %v, want %v", got, want)
+	}
+}
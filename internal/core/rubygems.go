@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+type Rubygems struct {
+	Server   string
+	Upstream *Upstream
+	FileName string
+}
+
+func NewRubygems(upstream *Upstream, fileName string) *Rubygems {
+	return &Rubygems{
+		Server:   upstream.URL,
+		Upstream: upstream,
+		FileName: fileName,
+	}
+}
+
+func (r Rubygems) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	// Get RUBYGEMS component
+	req, err := http.NewRequestWithContext(ctx, "GET", r.assetDownloadURL(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Send request
+	return r.Upstream.do(req, 180) // Set 3 min timeout to handle files
+}
+
+func (r *Rubygems) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
+	// Create multipart asset
+	boundary := utils.GenRandomBoundary(32)
+	fileName := r.FileName
+	const fileHeader = "Content-type: application/octet-stream"
+	const fileType = "rubygems.asset"
+	const fileFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\n%s\r\n\r\n"
+	bodyTop := fmt.Sprintf(fileFormat, boundary, fileType, fileName, fileHeader)
+	bodyBottom := fmt.Sprintf("\r\n--%s--\r\n", boundary)
+
+	body := io.MultiReader(strings.NewReader(bodyTop), fileReader, strings.NewReader(bodyBottom))
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return contentType, body
+}
+
+// assetDownloadURL returns 'gems/<name>-<version>.gem' url. File name is taken
+// from asset path as is to keep platform suffix for native gems
+func (r Rubygems) assetDownloadURL() string {
+	return fmt.Sprintf("%s/gems/%s", removeLastSlash(r.Server), r.FileName)
+}
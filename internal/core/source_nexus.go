@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/pkg/utils"
+	"path"
+	"strings"
+)
+
+// sourceNexusAsset downloads asset by download url of source nexus repository,
+// asset upload is prepared by wrapped format specific handler
+type sourceNexusAsset struct {
+	config.Asseter
+	upstream    *Upstream
+	downloadURL string
+}
+
+func (a sourceNexusAsset) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	resp, err := downloadFromSourceNexus(ctx, a.upstream, a.downloadURL)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+	return resp, nil
+}
+
+// sourceNexusComponent downloads component assets by download urls of source nexus repository,
+// component upload is prepared by wrapped format specific handler
+type sourceNexusComponent struct {
+	config.Componenter
+	upstream *Upstream
+	assets   []*NexusExportComponentAsset
+}
+
+func (c sourceNexusComponent) DownloadComponent(ctx context.Context) ([]*http.Response, error) {
+	responses := make([]*http.Response, 0, len(c.assets))
+	for _, v := range c.assets {
+		resp, err := downloadFromSourceNexus(ctx, c.upstream, v.DownloadURL)
+		if err != nil {
+			closeResponses(responses)
+			return nil, fmt.Errorf("DownloadComponent: %w", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses, nil
+}
+
+// sourceAsseter returns handler which downloads asset from source nexus repository.
+// Format specific handler is returned as is if asset download url is unknown
+func sourceAsseter(a config.Asseter, asset *NexusExportComponentAsset, upstream *Upstream) config.Asseter {
+	if asset.DownloadURL == "" {
+		return a
+	}
+	return &sourceNexusAsset{Asseter: a, upstream: upstream, downloadURL: asset.DownloadURL}
+}
+
+// sourceComponenter returns handler which downloads component assets from source nexus repository.
+// Format specific handler is returned as is if download url of any asset is unknown
+func sourceComponenter(c config.Componenter, assets []*NexusExportComponentAsset,
+	upstream *Upstream) config.Componenter {
+	for _, v := range assets {
+		if v.DownloadURL == "" {
+			return c
+		}
+	}
+	return &sourceNexusComponent{Componenter: c, upstream: upstream, assets: assets}
+}
+
+// downloadFromSourceNexus will download asset from source nexus repository. Download url is taken from
+// client request, so only urls inside of source repository are requested to not leak its credentials
+func downloadFromSourceNexus(ctx context.Context, upstream *Upstream, downloadURL string) (*http.Response, error) {
+	if !inSourceRepository(upstream.URL, downloadURL) {
+		return nil, &utils.ContextError{
+			Context: "downloadFromSourceNexus",
+			Err:     fmt.Errorf("error: download url %s is outside of source repository %s", downloadURL, upstream.URL),
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", downloadURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("downloadFromSourceNexus: %w", err)
+	}
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Send request with source nexus credentials
+	return upstream.do(req, 900) // Set 15 min timeout to handle large files
+}
+
+// inSourceRepository reports whether download url is located inside of source repository url.
+// Url path is cleaned to not allow leaving repository with dot segments
+func inSourceRepository(repoURL string, downloadURL string) bool {
+	u, err := url.Parse(downloadURL)
+	if err != nil || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return false
+	}
+	cleanPath := path.Clean(u.Path)
+	if strings.HasSuffix(u.Path, "/") {
+		cleanPath += "/"
+	}
+	if cleanPath != u.Path || (u.RawPath != "" && strings.Contains(strings.ToLower(u.RawPath), "%2e")) {
+		return false
+	}
+	return hasURLPrefix(downloadURL, repoURL)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestNexusServer_UploadComponents_sourceNexus(t *testing.T) {
+	// Source nexus is serving assets of hosted repository to its users only
+	source := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if user, pass, ok := r.BasicAuth(); !ok || user != "reader" || pass != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/repository/hosted/pkg/-/pkg-1.0.0.tgz":
+			_, _ = w.Write([]byte("npm-data"))
+		case "/repository/hosted/tools/app.tar.gz":
+			_, _ = w.Write([]byte("raw-data"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer source.Close()
+
+	var mu sync.Mutex
+	var uploaded []string
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			t.Error(err)
+		}
+		mu.Lock()
+		uploaded = append(uploaded, string(body))
+		mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer nexus.Close()
+
+	repoURL := source.URL + "/repository/hosted/"
+	asset := func(name string, path string) *NexusExportComponentAsset {
+		return &NexusExportComponentAsset{Name: name, Version: "1.0.0", FileName: AssetFileNameFromURI(path),
+			Path: path, DownloadURL: repoURL + path}
+	}
+	nec := &NexusExportComponents{
+		Items: []*NexusExportComponent{
+			{Name: "pkg", Version: "1.0.0", Format: "npm", ArtifactsSource: repoURL,
+				Assets: []*NexusExportComponentAsset{asset("pkg", "pkg/-/pkg-1.0.0.tgz")}},
+			{Name: "tools/app.tar.gz", Format: "raw", ArtifactsSource: repoURL,
+				Assets: []*NexusExportComponentAsset{asset("tools/app.tar.gz", "tools/app.tar.gz")}},
+		},
+		Upstreams: []*Upstream{{URL: repoURL, Auth: &UpstreamAuth{Username: "reader", Password: "secret"}}},
+	}
+	s := &NexusServer{Host: nexus.URL, Upstreams: nec.Upstreams}
+	results := s.UploadComponents(context.Background(), nec, "repo", &config.Server{Concurrency: 1}, nil)
+	for _, v := range results {
+		if v.Err != nil || v.Source != repoURL {
+			t.Errorf("UploadComponents() result = %+v", v)
+		}
+	}
+	if len(results) != 2 || len(uploaded) != 2 {
+		t.Fatalf("UploadComponents() results = %v, uploaded %d", results, len(uploaded))
+	}
+	// Assets are uploaded with format specific multipart fields
+	body := strings.Join(uploaded, "")
+	for _, v := range []string{`name="npm.asset"`, "npm-data", `name="raw.asset1"`, "raw-data"} {
+		if !strings.Contains(body, v) {
+			t.Errorf("uploaded body doesn't contain %s", v)
+		}
+	}
+}
+
+func Test_inSourceRepository(t *testing.T) {
+	const repoURL = "https://nexus.some/repository/hosted/"
+	tests := []struct {
+		name        string
+		downloadURL string
+		want        bool
+	}{
+		{"asset of repository", repoURL + "pkg/-/pkg-1.0.0.tgz", true},
+		{"another repository", "https://nexus.some/repository/hosted2/pkg/-/pkg-1.0.0.tgz", false},
+		{"another host", "https://metadata.internal/repository/hosted/pkg-1.0.0.tgz", false},
+		{"dot segments", repoURL + "../other/pkg-1.0.0.tgz", false},
+		{"encoded dot segments", repoURL + "%2e%2e/other/pkg-1.0.0.tgz", false},
+		{"user info", "https://nexus.some@evil.some/repository/hosted/pkg-1.0.0.tgz", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := inSourceRepository(repoURL, tt.downloadURL); got != tt.want {
+				t.Errorf("inSourceRepository() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"strings"
+	"sync"
+)
+
+// SourceHistory remembers fallback artifacts source which served every package,
+// so it's tried first at next upload of the same package
+type SourceHistory struct {
+	mu      sync.Mutex
+	sources map[string]string
+}
+
+func NewSourceHistory() *SourceHistory {
+	return &SourceHistory{sources: make(map[string]string)}
+}
+
+// order returns sources with remembered source of package first. Nil history keeps sources order
+func (h *SourceHistory) order(key string, sources []string) []string {
+	if h == nil || len(sources) < 2 {
+		return sources
+	}
+	h.mu.Lock()
+	remembered, ok := h.sources[key]
+	h.mu.Unlock()
+	if !ok || remembered == sources[0] {
+		return sources
+	}
+	ordered := make([]string, 0, len(sources))
+	for _, v := range sources {
+		if v == remembered {
+			ordered = append([]string{v}, ordered...)
+		} else {
+			ordered = append(ordered, v)
+		}
+	}
+	return ordered
+}
+
+// remember will save source which served package. Primary source is not saved, because it's tried first anyway
+func (h *SourceHistory) remember(key string, primary string, source string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if source == primary {
+		delete(h.sources, key)
+		return
+	}
+	h.sources[key] = source
+}
+
+// downloadError is marking failed download from artifacts source, so package may be taken from another one
+type downloadError struct {
+	err error
+}
+
+func (e *downloadError) Error() string { return e.err.Error() }
+func (e *downloadError) Unwrap() error { return e.err }
+
+// SourceError is holding failure reason of one artifacts source
+type SourceError struct {
+	Source string
+	Err    error
+}
+
+func (e *SourceError) Error() string { return fmt.Sprintf("%s: %v", e.Source, e.Err) }
+func (e *SourceError) Unwrap() error { return e.Err }
+
+// SourcesError is returned when package can't be taken from any of artifacts sources
+type SourcesError struct {
+	Errors []*SourceError
+}
+
+func (e *SourcesError) Error() string {
+	reasons := make([]string, 0, len(e.Errors))
+	for _, v := range e.Errors {
+		reasons = append(reasons, v.Error())
+	}
+	return fmt.Sprintf("all artifacts sources failed: %s", strings.Join(reasons, "; "))
+}
+
+// uploadFromSources will upload package from artifacts sources in order until one of them succeeds.
+// The next source is tried only if package download is failed. Source which served package is returned
+func (s *NexusServer) uploadFromSources(ctx context.Context, key string, sources []string,
+	upload func(upstream *Upstream) error) (string, error) {
+	var failures []*SourceError
+	for _, v := range s.Sources.order(key, sources) {
+		err := upload(s.upstream(v))
+		if err == nil {
+			if v != sources[0] {
+				log.Printf("Package %s is taken from fallback artifacts source %s", key, v)
+			}
+			s.Sources.remember(key, sources[0], v)
+			return v, nil
+		}
+		failures = append(failures, &SourceError{Source: v, Err: err})
+		var de *downloadError
+		if ctx.Err() != nil || !errors.As(err, &de) {
+			break
+		}
+	}
+	// Keep error of the only source as is
+	if len(sources) == 1 || len(failures) == 1 {
+		return "", failures[0].Err
+	}
+	return "", &SourcesError{Errors: failures}
+}
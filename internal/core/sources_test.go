@@ -0,0 +1,128 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+func TestSourceHistory_order(t *testing.T) {
+	sources := []string{"primary", "fallback1", "fallback2"}
+	h := NewSourceHistory()
+	h.remember("npm/repo1//pkg1", "primary", "fallback2")
+	h.remember("npm/repo1//pkg2", "primary", "fallback1")
+	h.remember("npm/repo1//pkg2", "primary", "primary")
+	tests := []struct {
+		name    string
+		history *SourceHistory
+		key     string
+		want    []string
+	}{
+		{"remembered fallback first", h, "npm/repo1//pkg1", []string{"fallback2", "primary", "fallback1"}},
+		{"primary is forgotten", h, "npm/repo1//pkg2", sources},
+		{"unknown package", h, "npm/repo1//pkg3", sources},
+		{"nil history", nil, "npm/repo1//pkg1", sources},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.history.order(tt.key, sources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("order() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNexusServer_UploadComponents_fallback(t *testing.T) {
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	// newUpstream returns npm registry serving packages from list
+	newUpstream := func(name string, packages ...string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			requests[name]++
+			mu.Unlock()
+			for _, v := range packages {
+				if r.URL.Path == "/"+v+"/-/"+v+"-1.0.0.tgz" {
+					_, _ = w.Write([]byte(v))
+					return
+				}
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	}
+	primary := newUpstream("primary", "pkg1")
+	defer primary.Close()
+	fallback := newUpstream("fallback", "pkg1", "pkg2")
+	defer fallback.Close()
+	nexus := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer nexus.Close()
+
+	primaryURL, fallbackURL := primary.URL+"/", fallback.URL+"/"
+	component := func(name string) *NexusExportComponent {
+		return &NexusExportComponent{
+			Name:            name,
+			Version:         "1.0.0",
+			Format:          "npm",
+			ArtifactsSource: primaryURL,
+			FallbackSources: []string{fallbackURL},
+			Assets: []*NexusExportComponentAsset{
+				{Name: name, Version: "1.0.0", FileName: name + "-1.0.0.tgz", Path: name + "/-/" + name + "-1.0.0.tgz"},
+			},
+		}
+	}
+	s := &NexusServer{Host: nexus.URL, Sources: NewSourceHistory()}
+	upload := func(name string) UploadResult {
+		results := s.UploadComponents(context.Background(), &NexusExportComponents{
+			Items: []*NexusExportComponent{component(name)},
+		}, "npm-repo", &config.Server{Concurrency: 1}, nil)
+		if len(results) != 1 {
+			t.Fatalf("UploadComponents() results = %v", results)
+		}
+		return results[0]
+	}
+
+	if got := upload("pkg1"); got.Err != nil || got.Source != primaryURL {
+		t.Errorf("UploadComponents() = %+v, want primary source", got)
+	}
+	if got := upload("pkg2"); got.Err != nil || got.Source != fallbackURL {
+		t.Errorf("UploadComponents() = %+v, want fallback source", got)
+	}
+	// Fallback which served package last time is tried first
+	primaryRequests := requests["primary"]
+	if got := upload("pkg2"); got.Err != nil || got.Source != fallbackURL || requests["primary"] != primaryRequests {
+		t.Errorf("UploadComponents() = %+v, primary requests = %d, want fallback source only",
+			got, requests["primary"]-primaryRequests)
+	}
+
+	// Failure reason of every source is reported
+	got := upload("pkg3")
+	var sourcesErr *SourcesError
+	if !errors.As(got.Err, &sourcesErr) || len(sourcesErr.Errors) != 2 || got.Source != "" {
+		t.Fatalf("UploadComponents() = %+v, want errors of both sources", got)
+	}
+	if sourcesErr.Errors[0].Source != primaryURL || sourcesErr.Errors[1].Source != fallbackURL {
+		t.Errorf("UploadComponents() errors = %v, want sources in order", sourcesErr.Errors)
+	}
+}
+
+func TestNexusServer_uploadFromSources_uploadError(t *testing.T) {
+	s := &NexusServer{}
+	var tried []string
+	uploadErr := errors.New("destination is unavailable")
+	_, err := s.uploadFromSources(context.Background(), "npm/repo1//pkg1", []string{"primary", "fallback"},
+		func(upstream *Upstream) error {
+			tried = append(tried, upstream.URL)
+			return uploadErr
+		})
+	// Another source can't help with failed upload to destination
+	if !errors.Is(err, uploadErr) || !reflect.DeepEqual(tried, []string{"primary"}) {
+		t.Errorf("uploadFromSources() error = %v, tried = %v", err, tried)
+	}
+}
@@ -1,22 +1,23 @@
 package core
 
 import (
+	"context"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"nexus-pusher/internal/config"
-	"nexus-pusher/pkg/http_clients"
 	"nexus-pusher/pkg/utils"
+	"strings"
 	"time"
 )
 
-func (s *NexusServer) uploadComponent(format config.ComponentType,
-	component *NexusExportComponent, repoName string) error {
+func (s *NexusServer) uploadComponent(ctx context.Context, format config.ComponentType,
+	component *NexusExportComponent, repoName string, upstream *Upstream) error {
 	switch format.Lower() {
 	case config.MAVEN2:
-		maven2 := NewMaven2(component.ArtifactsSource, component)
+		maven2 := NewMaven2(upstream, component)
 
 		if len(maven2.Component.Assets) == 0 {
 			return &utils.ContextError{
@@ -26,7 +27,7 @@ func (s *NexusServer) uploadComponent(format config.ComponentType,
 		}
 
 		// Start to download data and convert it to multipart stream
-		contentType, uploadBody, responses, err := prepareToUploadComponent(maven2)
+		contentType, uploadBody, responses, err := prepareToUploadComponent(ctx, sourceComponenter(maven2, maven2.Component.Assets, upstream))
 		if err != nil {
 			return fmt.Errorf("uploadComponent: %w", err)
 		}
@@ -39,59 +40,169 @@ func (s *NexusServer) uploadComponent(format config.ComponentType,
 		}()
 
 		// Upload component to target nexus server
-		if err := s.uploadComponentWithType(repoName, component.FullName(), contentType, uploadBody); err != nil {
+		if err := s.uploadComponentWithType(ctx, repoName, component.FullName(), contentType, uploadBody); err != nil {
 			return fmt.Errorf("uploadComponent: %w", err)
 		}
+
+	case config.RAW:
+		raw := NewRaw(upstream, component)
+
+		// Start to download data and convert it to multipart stream
+		contentType, uploadBody, responses, err := prepareToUploadComponent(ctx, sourceComponenter(raw, raw.Component.Assets, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadComponent: %w", err)
+		}
+
+		// Close all responses body
+		defer func() {
+			for _, resp := range responses {
+				resp.Body.Close()
+			}
+		}()
+
+		// Upload component to target nexus server
+		if err := s.uploadComponentWithType(ctx, repoName, component.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadComponent: %w", err)
+		}
+
+	case config.GO:
+		goModule := NewGoModule(upstream, s.repositoryURL(repoName), s.TLS,
+			s.Username, s.Password, component)
+
+		// There is no upload API for go format, so warm destination proxy instead
+		if err := goModule.WarmProxy(ctx); err != nil {
+			return fmt.Errorf("uploadComponent: %w", err)
+		}
+
+		log.Printf("Module %s successfully cached in repository '%s' at server %s",
+			component.FullName(),
+			repoName,
+			s.Host)
 	}
 
 	return nil
 }
 
-func (s *NexusServer) uploadAsset(format config.ComponentType, asset *NexusExportComponentAsset,
-	repoName string, artifactsSource string) error {
+func (s *NexusServer) uploadAsset(ctx context.Context, format config.ComponentType, asset *NexusExportComponentAsset,
+	repoName string, upstream *Upstream) error {
 	switch format.Lower() {
 	case config.NPM:
-		npm := NewNpm(artifactsSource, asset.Path, asset.FileName)
+		npm := NewNpm(upstream, asset.Path, asset.FileName)
 
 		// Start to download data and convert it to multipart stream
-		contentType, uploadBody, resp, err := prepareToUploadAsset(npm)
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(npm, asset, upstream))
 		if err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 		defer resp.Body.Close()
 
 		// Upload component to target nexus server
-		if err := s.uploadComponentWithType(repoName, asset.FullName(), contentType, uploadBody); err != nil {
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 
 	case config.PYPI:
-		pypi := NewPypi(artifactsSource, asset.Path, asset.FileName, asset.Name, asset.Version)
+		pypi := NewPypi(upstream, asset.Path, asset.FileName, asset.Name, asset.Version)
 
 		// Start to download data and convert it to multipart stream
-		contentType, uploadBody, resp, err := prepareToUploadAsset(pypi)
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(pypi, asset, upstream))
 		if err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 		defer resp.Body.Close()
 
 		// Upload component to target nexus server
-		if err := s.uploadComponentWithType(repoName, asset.FullName(), contentType, uploadBody); err != nil {
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 
 	case config.NUGET:
-		nuget := NewNuget(artifactsSource, asset.FileName, asset.Name, asset.Version)
+		nuget := NewNuget(upstream, asset.FileName, asset.Name, asset.Version)
+
+		// Start to download data and convert it to multipart stream
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(nuget, asset, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Upload component to target nexus server
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+
+	case config.HELM:
+		helm := NewHelm(upstream, asset.FileName, asset.Name, asset.Version)
+
+		// Start to download data and convert it to multipart stream
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(helm, asset, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Upload component to target nexus server
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+
+	case config.RUBY:
+		rubygems := NewRubygems(upstream, asset.FileName)
+
+		// Start to download data and convert it to multipart stream
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(rubygems, asset, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Upload component to target nexus server
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+
+	case config.APT:
+		apt := NewApt(upstream, asset.Path, asset.FileName)
+
+		// Start to download data and convert it to multipart stream
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(apt, asset, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Upload component to target nexus server
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+
+	case config.YUM:
+		yum := NewYum(upstream, asset.Path, asset.FileName)
 
 		// Start to download data and convert it to multipart stream
-		contentType, uploadBody, resp, err := prepareToUploadAsset(nuget)
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(yum, asset, upstream))
 		if err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 		defer resp.Body.Close()
 
 		// Upload component to target nexus server
-		if err := s.uploadComponentWithType(repoName, asset.FullName(), contentType, uploadBody); err != nil {
+		if err := s.uploadComponentWithType(ctx, repoName, asset.FullName(), contentType, uploadBody); err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+
+	case config.CONDA:
+		conda := NewConda(upstream, asset.Path, asset.FileName)
+
+		// Start to download data
+		contentType, uploadBody, resp, err := prepareToUploadAsset(ctx, sourceAsseter(conda, asset, upstream))
+		if err != nil {
+			return fmt.Errorf("uploadAsset: %w", err)
+		}
+		defer resp.Body.Close()
+
+		// Upload package to target nexus server following its subdir path
+		if err := s.uploadAssetWithPut(ctx, repoName, conda.subdirPath(), contentType, uploadBody); err != nil {
 			return fmt.Errorf("uploadAsset: %w", err)
 		}
 	}
@@ -99,26 +210,64 @@ func (s *NexusServer) uploadAsset(format config.ComponentType, asset *NexusExpor
 	return nil
 }
 
+// uploadImage copies docker image following component name and tag to destination docker connector
+func (s *NexusServer) uploadImage(ctx context.Context, component *NexusExportComponent, repoName string,
+	upstream *Upstream) error {
+	docker := NewDocker(upstream, s.dockerConnectorURL(repoName), s.TLS,
+		s.Username, s.Password, component.Name, component.Version)
+
+	if err := docker.CopyImage(ctx); err != nil {
+		return fmt.Errorf("uploadImage: %w", err)
+	}
+
+	log.Printf("Image %s:%s successfully uploaded to repository '%s' at server %s",
+		component.Name,
+		component.Version,
+		repoName,
+		s.Host)
+
+	return nil
+}
+
+// dockerConnectorURL returns docker registry API address of destination repository.
+// If there is no dedicated connector, nexus repository path based routing is used
+func (s *NexusServer) dockerConnectorURL(repoName string) string {
+	if s.DockerConnector != "" {
+		return s.DockerConnector
+	}
+	return s.repositoryURL(repoName)
+}
+
+// repositoryURL returns base address of repository content
+func (s *NexusServer) repositoryURL(repoName string) string {
+	return fmt.Sprintf("%s/repository/%s", removeLastSlash(s.Host), repoName)
+}
+
 // Download component with all assets following provided interface type
-func prepareToUploadComponent(c config.Componenter) (string, io.Reader, []*http.Response, error) {
+func prepareToUploadComponent(ctx context.Context, c config.Componenter) (string, io.Reader, []*http.Response, error) {
 	// Start downloading component from remote repo
-	responses, err := c.DownloadComponent()
+	responses, err := c.DownloadComponent(ctx)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("prepareToUploadComponent: %w", err)
+		return "", nil, nil, &downloadError{fmt.Errorf("prepareToUploadComponent: %w", err)}
 	}
 
 	for _, resp := range responses {
 		if resp.StatusCode != http.StatusOK {
-			return "", nil, nil, &utils.ContextError{
+			closeResponses(responses)
+			return "", nil, nil, &downloadError{&utils.ContextError{
 				Context: "prepareToUploadComponent",
 				Err: fmt.Errorf("unable to download asset. sending '%s' request: status code %d %v",
 					resp.Request.Method,
 					resp.StatusCode,
 					resp.Request.URL),
-			}
+			}}
 		}
 	}
 
+	for _, resp := range responses {
+		resp.Body = countTransferred(ctx, resp.Body)
+	}
+
 	// Convert to multipart component specific type on the fly
 	// and return converted body with correct content type
 	contentType, uploadBody := c.PrepareComponentToUpload(responses)
@@ -126,37 +275,39 @@ func prepareToUploadComponent(c config.Componenter) (string, io.Reader, []*http.
 }
 
 // Download asset following provided interface type
-func prepareToUploadAsset(a config.Asseter) (string, io.Reader, *http.Response, error) {
+func prepareToUploadAsset(ctx context.Context, a config.Asseter) (string, io.Reader, *http.Response, error) {
 	// Start downloading asset from remote repo
-	resp, err := a.DownloadAsset()
+	resp, err := a.DownloadAsset(ctx)
 	if err != nil {
-		return "", nil, nil, fmt.Errorf("prepareToUploadAsset: %w", err)
+		return "", nil, nil, &downloadError{fmt.Errorf("prepareToUploadAsset: %w", err)}
 	}
 
 	// Check http response ok status
 	if resp.StatusCode != http.StatusOK {
-		return "", nil, nil, &utils.ContextError{
+		resp.Body.Close()
+		return "", nil, nil, &downloadError{&utils.ContextError{
 			Context: "prepareToUploadAsset",
 			Err: fmt.Errorf("unable to download asset. sending '%s' request: status code %d %v",
 				resp.Request.Method,
 				resp.StatusCode,
 				resp.Request.URL),
-		}
+		}}
 	}
 
 	// Convert to multipart component specific type on the fly
 	// and return converted body with correct content type
+	resp.Body = countTransferred(ctx, resp.Body)
 	contentType, uploadBody := a.PrepareAssetToUpload(resp.Body)
 	return contentType, uploadBody, resp, nil
 }
 
-func (s *NexusServer) uploadComponentWithType(repoName string, cPath string, contentType string, body io.Reader) error {
+func (s *NexusServer) uploadComponentWithType(ctx context.Context, repoName string, cPath string, contentType string, body io.Reader) error {
 	// Upload component to nexus repo
 	srvUrl := fmt.Sprintf("%s%s%s?repository=%s", s.Host,
 		s.BaseUrl,
 		s.ApiComponentsUrl,
 		repoName)
-	req, err := http.NewRequest("POST", srvUrl, body)
+	req, err := http.NewRequestWithContext(ctx, "POST", srvUrl, body)
 	if err != nil {
 		return fmt.Errorf("uploadComponentWithType: %w", err)
 	}
@@ -170,9 +321,10 @@ func (s *NexusServer) uploadComponentWithType(repoName string, cPath string, con
 	// let's implement simple retry behaviour
 	var resp *http.Response
 	for i := 1; i <= 4; {
-		resp, err = http_clients.HttpClient(900).Do(req)
+		resp, err = s.client(900).Do(req)
 		if err != nil {
-			if i == 4 {
+			// Don't retry canceled upload
+			if i == 4 || ctx.Err() != nil {
 				// if it's last iteration, return error
 				return fmt.Errorf("uploadComponentWithType: %w", err)
 			} else {
@@ -215,3 +367,51 @@ func (s *NexusServer) uploadComponentWithType(repoName string, cPath string, con
 
 	return nil
 }
+
+// uploadAssetWithPut upload asset to repository path for formats without components API support
+func (s *NexusServer) uploadAssetWithPut(ctx context.Context, repoName string, aPath string, contentType string, body io.Reader) error {
+	srvUrl := fmt.Sprintf("%s/%s", s.repositoryURL(repoName), strings.TrimPrefix(aPath, "/"))
+	req, err := http.NewRequestWithContext(ctx, "PUT", srvUrl, body)
+	if err != nil {
+		return fmt.Errorf("uploadAssetWithPut: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.SetBasicAuth(s.Username, s.Password)
+
+	// Start uploading asset to remote nexus
+	// Set 15 min timeout to handle large files
+	// Request can't be retried because body
+	// is a stream of data from remote repo
+	resp, err := s.client(900).Do(req)
+	if err != nil {
+		return fmt.Errorf("uploadAssetWithPut: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Check server response
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated &&
+		resp.StatusCode != http.StatusNoContent {
+		// Read response body with error
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			return fmt.Errorf("uploadAssetWithPut: %w", err)
+		}
+
+		// Create formatted message
+		const msg = "unable to upload asset %s to repository '%s' at server %s. Reason: %s. Response: %s"
+
+		// Return error
+		return fmt.Errorf(msg, aPath, repoName, s.Host, resp.Status, string(body))
+	}
+
+	log.Printf("Asset %s successfully uploaded to repository '%s' at server %s",
+		aPath,
+		repoName,
+		s.Host)
+
+	if _, err := io.Copy(ioutil.Discard, resp.Body); err != nil {
+		return fmt.Errorf("uploadAssetWithPut: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,140 @@
+package core
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/pkg/http_clients"
+	"strings"
+)
+
+// Upstream is a source of artifacts with its connection options
+type Upstream struct {
+	URL string `json:"url"`
+	// TLS is holding connection options of upstream, they are never sent to nexus-pusher server
+	TLS *tls.Config `json:"-"`
+	// Auth is holding credentials of upstream, nil for anonymous access
+	Auth *UpstreamAuth `json:"auth,omitempty"`
+}
+
+// UpstreamAuth is holding credentials which are sent with every upstream request
+type UpstreamAuth struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	// Token is sent as a bearer token
+	Token   string            `json:"token,omitempty"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+// NewUpstream returns artifacts source with default connection options
+func NewUpstream(url string) *Upstream {
+	return &Upstream{URL: url}
+}
+
+// client returns http client to request upstream with optional timeout parameter.
+// Default connection options are used for nil upstream
+func (u *Upstream) client(seconds ...int) *http.Client {
+	if u == nil {
+		return http_clients.HttpRetryClient(seconds...)
+	}
+	return http_clients.HttpRetryClientTLS(u.TLS, seconds...)
+}
+
+// do will send request to upstream with its credentials and optional timeout parameter
+func (u *Upstream) do(req *http.Request, seconds ...int) (*http.Response, error) {
+	u.authorize(req)
+	return u.client(seconds...).Do(req)
+}
+
+// authorize will set upstream credentials to request. Credentials are set only for requests
+// to upstream host, so they are not leaked to CDN or another registry which files are served from
+func (u *Upstream) authorize(req *http.Request) {
+	if u == nil || u.Auth == nil {
+		return
+	}
+	upstreamURL, err := url.Parse(u.URL)
+	if err != nil || !strings.EqualFold(upstreamURL.Host, req.URL.Host) {
+		return
+	}
+	for k, v := range u.Auth.Headers {
+		req.Header.Set(k, v)
+	}
+	if u.Auth.Token != "" {
+		req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", u.Auth.Token))
+	} else if u.Auth.Username != "" {
+		req.SetBasicAuth(u.Auth.Username, u.Auth.Password)
+	}
+}
+
+// upstream returns artifacts source with connection options of configured upstreams
+func (s *NexusServer) upstream(source string) *Upstream {
+	return resolveUpstream(s.Upstreams, source)
+}
+
+// HasUpstreamAuth reports whether credentials of artifacts source are set by configured upstreams
+func (s *NexusServer) HasUpstreamAuth(source string) bool {
+	return s.upstream(source).Auth != nil
+}
+
+// resolveUpstream returns artifacts source with TLS options and credentials of the most specific upstreams
+// which url is a prefix of source. Credentials of later upstreams take precedence for the same url
+func resolveUpstream(upstreams []*Upstream, source string) *Upstream {
+	u := NewUpstream(source)
+	tlsMatched, authMatched := -1, -1
+	for _, v := range upstreams {
+		if !hasURLPrefix(source, v.URL) {
+			continue
+		}
+		prefix := removeLastSlash(v.URL)
+		if v.TLS != nil && len(prefix) > tlsMatched {
+			u.TLS = v.TLS
+			tlsMatched = len(prefix)
+		}
+		if v.Auth != nil && len(prefix) >= authMatched {
+			u.Auth = v.Auth
+			authMatched = len(prefix)
+		}
+	}
+	return u
+}
+
+// hasURLPrefix reports whether source url is equal to prefix url or is located under its path
+func hasURLPrefix(source string, prefix string) bool {
+	prefix = removeLastSlash(prefix)
+	return source == prefix || strings.HasPrefix(source, prefix+"/")
+}
+
+// client returns http client to request nexus server with optional timeout parameter
+func (s *NexusServer) client(seconds ...int) *http.Client {
+	return http_clients.HttpClientTLS(s.TLS, seconds...)
+}
+
+// TLSConfig returns TLS config of connection following config options, nil is returned if no options are set
+func TLSConfig(t config.TLSClient) (*tls.Config, error) {
+	return http_clients.TLSConfig(http_clients.TLSOptions{
+		CAPath:             t.CAPath,
+		CertPath:           t.CertPath,
+		KeyPath:            t.KeyPath,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+		ServerName:         t.ServerName,
+	})
+}
+
+// NewUpstreamAuth returns credentials of artifacts source following config options,
+// nil is returned if no credentials are set. Auth token of npm registry is taken from .npmrc file
+func NewUpstreamAuth(source string, a config.UpstreamAuth) (*UpstreamAuth, error) {
+	auth := &UpstreamAuth{Username: a.User, Password: a.Pass, Token: a.Token, Headers: a.Headers}
+	if a.Npmrc != "" {
+		npmrcAuth, err := readNpmrcAuth(a.Npmrc, source)
+		if err != nil {
+			return nil, fmt.Errorf("NewUpstreamAuth: %w", err)
+		}
+		auth.Username, auth.Password, auth.Token = npmrcAuth.Username, npmrcAuth.Password, npmrcAuth.Token
+	}
+	if auth.Username == "" && auth.Token == "" && len(auth.Headers) == 0 {
+		return nil, nil
+	}
+	return auth, nil
+}
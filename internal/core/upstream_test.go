@@ -0,0 +1,158 @@
+package core
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestNexusServer_upstream(t *testing.T) {
+	npm := &tls.Config{ServerName: "npm"}
+	private := &tls.Config{ServerName: "private"}
+	s := &NexusServer{Upstreams: []*Upstream{
+		{URL: "https://artifactory.some/npm/", TLS: npm},
+		{URL: "https://artifactory.some/npm/private", TLS: private},
+	}}
+	tests := []struct {
+		name   string
+		source string
+		want   *tls.Config
+	}{
+		{"prefix", "https://artifactory.some/npm/public", npm},
+		{"the most specific prefix", "https://artifactory.some/npm/private/", private},
+		{"same url", "https://artifactory.some/npm", npm},
+		{"not a path prefix", "https://artifactory.some/npm-remote", nil},
+		{"unknown", "https://registry.npmjs.org", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := s.upstream(tt.source)
+			if got.URL != tt.source {
+				t.Errorf("upstream() URL = %s, want %s", got.URL, tt.source)
+			}
+			if got.TLS != tt.want {
+				t.Errorf("upstream() TLS = %+v, want %+v", got.TLS, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpstream_customCA(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("package"))
+	}))
+	defer srv.Close()
+	caPath := filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := ioutil.WriteFile(caPath, ca, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	// Test server certificate is trusted through upstream CA bundle only
+	tlsConfig, err := TLSConfig(config.TLSClient{CAPath: caPath})
+	if err != nil {
+		t.Fatalf("TLSConfig() error = %v", err)
+	}
+	s := &NexusServer{Upstreams: []*Upstream{{URL: srv.URL, TLS: tlsConfig}}}
+	resp, err := NewNpm(s.upstream(srv.URL), "/pkg.tgz", "pkg.tgz").DownloadAsset(context.Background())
+	if err != nil {
+		t.Fatalf("DownloadAsset() error = %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("DownloadAsset() status = %d", resp.StatusCode)
+	}
+}
+
+func TestUpstream_authorize(t *testing.T) {
+	tests := []struct {
+		name       string
+		auth       *UpstreamAuth
+		url        string
+		wantAuth   string
+		wantHeader string
+	}{
+		{"basic", &UpstreamAuth{Username: "user", Password: "pass"}, "https://private.some/npm/pkg.tgz",
+			"Basic " + base64.StdEncoding.EncodeToString([]byte("user:pass")), "key"},
+		{"bearer token", &UpstreamAuth{Token: "secret"}, "https://private.some/npm/pkg.tgz", "Bearer secret", "key"},
+		{"another host", &UpstreamAuth{Token: "secret"}, "https://cdn.some/npm/pkg.tgz", "", ""},
+		{"anonymous", nil, "https://private.some/npm/pkg.tgz", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.auth != nil {
+				tt.auth.Headers = map[string]string{"X-Api-Key": "key"}
+			}
+			u := &Upstream{URL: "https://private.some/npm", Auth: tt.auth}
+			req := httptest.NewRequest("GET", tt.url, nil)
+			u.authorize(req)
+			if got := req.Header.Get("Authorization"); got != tt.wantAuth {
+				t.Errorf("authorize() Authorization = %s, want %s", got, tt.wantAuth)
+			}
+			if got := req.Header.Get("X-Api-Key"); got != tt.wantHeader {
+				t.Errorf("authorize() X-Api-Key = %s, want %s", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func Test_resolveUpstream_auth(t *testing.T) {
+	configured := &UpstreamAuth{Token: "server"}
+	sent := &UpstreamAuth{Token: "client"}
+	upstreams := []*Upstream{
+		{URL: "https://private.some", Auth: configured},
+		{URL: "https://private.some/npm", TLS: &tls.Config{}},
+		{URL: "https://private.some/npm", Auth: sent},
+	}
+	if got := resolveUpstream(upstreams, "https://private.some/npm/"); got.Auth != sent || got.TLS == nil {
+		t.Errorf("resolveUpstream() = %+v, want auth sent by client", got)
+	}
+	if got := resolveUpstream(upstreams, "https://private.some/pypi"); got.Auth != configured || got.TLS != nil {
+		t.Errorf("resolveUpstream() = %+v, want configured auth", got)
+	}
+}
+
+func Test_readNpmrcAuth(t *testing.T) {
+	t.Setenv("NPM_TOKEN", "env-token")
+	path := filepath.Join(t.TempDir(), ".npmrc")
+	npmrc := `registry=https://npm.corp.some/
+always-auth=true
+//npm.corp.some/:_authToken=${NPM_TOKEN}
+//npm.corp.some/private/:_auth=` + base64.StdEncoding.EncodeToString([]byte("user:pass")) + `
+//npm.pkg.github.com/:username=gh-user
+//npm.pkg.github.com/:_password=` + base64.StdEncoding.EncodeToString([]byte("gh-pass")) + `
+`
+	if err := ioutil.WriteFile(path, []byte(npmrc), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name     string
+		registry string
+		want     *UpstreamAuth
+		wantErr  bool
+	}{
+		{"token from environment", "https://npm.corp.some", &UpstreamAuth{Token: "env-token"}, false},
+		{"the most specific scope", "https://npm.corp.some/private/", &UpstreamAuth{Username: "user", Password: "pass"}, false},
+		{"username and password", "https://npm.pkg.github.com/", &UpstreamAuth{Username: "gh-user", Password: "gh-pass"}, false},
+		{"unknown registry", "https://registry.npmjs.org/", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readNpmrcAuth(path, tt.registry)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readNpmrcAuth() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readNpmrcAuth() got = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package core
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"nexus-pusher/pkg/utils"
+	"path"
+	"strings"
+)
+
+type Yum struct {
+	Server   string
+	Upstream *Upstream
+	Path     string
+	FileName string
+}
+
+func NewYum(upstream *Upstream, path string, fileName string) *Yum {
+	return &Yum{
+		Server:   upstream.URL,
+		Upstream: upstream,
+		Path:     path,
+		FileName: fileName,
+	}
+}
+
+func (y Yum) DownloadAsset(ctx context.Context) (*http.Response, error) {
+	// Get YUM package
+	assetURL, err := y.assetDownloadURL(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", assetURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("DownloadAsset: %w", err)
+	}
+
+	req.Header.Set("Accept", "application/octet-stream")
+
+	// Send request
+	return y.Upstream.do(req, 900) // Set 15 min timeout to handle large files
+}
+
+func (y *Yum) PrepareAssetToUpload(fileReader io.Reader) (string, io.Reader) {
+	// Create multipart asset
+	boundary := utils.GenRandomBoundary(32)
+	fileName := y.FileName
+	const fileHeader = "Content-type: application/octet-stream"
+	const fileType = "yum.asset"
+	const fieldFileName = "yum.asset.filename"
+	const fieldDirectory = "yum.directory"
+	const fileFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"; filename=\"%s\"\r\n%s\r\n\r\n"
+	const fieldFormat = "--%s\r\nContent-Disposition: form-data; name=\"%s\"\r\n\r\n%s\r\n"
+
+	// Keep original repository directory layout
+	directoryPart := fmt.Sprintf(fieldFormat, boundary, fieldDirectory, y.directory())
+	fileNamePart := fmt.Sprintf(fieldFormat, boundary, fieldFileName, fileName)
+	filePart := fmt.Sprintf(fileFormat, boundary, fileType, fileName, fileHeader)
+	bodyTop := fmt.Sprintf("%s%s%s", directoryPart, fileNamePart, filePart)
+	bodyBottom := fmt.Sprintf("\r\n--%s--\r\n", boundary)
+
+	body := io.MultiReader(strings.NewReader(bodyTop), fileReader, strings.NewReader(bodyBottom))
+	contentType := fmt.Sprintf("multipart/form-data; boundary=%s", boundary)
+	return contentType, body
+}
+
+// directory returns asset directory relative to repository root
+func (y Yum) directory() string {
+	dir := path.Dir(strings.TrimPrefix(y.Path, "/"))
+	if dir == "." {
+		return "/"
+	}
+	return dir
+}
+
+// yumIndex is holding package locations of yum repository by package file name
+type yumIndex map[string]string
+
+func (y Yum) assetDownloadURL(ctx context.Context) (string, error) {
+	server := removeLastSlash(y.Server)
+
+	// Repository metadata is shared by all packages, so it's downloaded once per job
+	index, err := repositoryIndex(ctx, "yum:"+server, func() (interface{}, error) {
+		return y.index(ctx)
+	})
+	if err != nil {
+		return "", fmt.Errorf("assetDownloadURL: %w", err)
+	}
+
+	location, err := y.packageLocation(index.(yumIndex))
+	if err != nil {
+		return "", fmt.Errorf("assetDownloadURL: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s", server, location), nil
+}
+
+// index returns package locations from primary metadata of repository
+func (y Yum) index(ctx context.Context) (yumIndex, error) {
+	server := removeLastSlash(y.Server)
+
+	// Find primary metadata location in repository index
+	repomd, err := y.get(ctx, fmt.Sprintf("%s/repodata/repomd.xml", server))
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	defer repomd.Body.Close()
+
+	primaryHref, err := primaryLocationFromRepomd(repomd.Body)
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+
+	// Collect package locations from primary metadata
+	primary, err := y.get(ctx, fmt.Sprintf("%s/%s", server, primaryHref))
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	defer primary.Body.Close()
+
+	var primaryReader io.Reader = primary.Body
+	if strings.HasSuffix(primaryHref, ".gz") {
+		gz, err := gzip.NewReader(primary.Body)
+		if err != nil {
+			return nil, fmt.Errorf("index: %w", err)
+		}
+		defer gz.Close()
+		primaryReader = gz
+	}
+
+	index, err := packageLocationsFromPrimary(primaryReader)
+	if err != nil {
+		return nil, fmt.Errorf("index: %w", err)
+	}
+	return index, nil
+}
+
+func (y Yum) get(ctx context.Context, requestURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+
+	// Send request
+	resp, err := y.Upstream.do(req, 180) // Metadata of big repos can be huge
+	if err != nil {
+		return nil, fmt.Errorf("get: %w", err)
+	}
+
+	// Check response for error
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, &utils.ContextError{
+			Context: "get",
+			Err: fmt.Errorf("error: unable to get yum repository metadata. sending '%s' request: status code %d %v",
+				resp.Request.Method,
+				resp.StatusCode,
+				resp.Request.URL),
+		}
+	}
+	return resp, nil
+}
+
+// primaryLocationFromRepomd returns location of primary metadata from repomd.xml data
+func primaryLocationFromRepomd(r io.Reader) (string, error) {
+	body, err := ioutil.ReadAll(r)
+	if err != nil {
+		return "", fmt.Errorf("primaryLocationFromRepomd: %w", err)
+	}
+
+	repomd := &struct {
+		Data []struct {
+			Type     string `xml:"type,attr"`
+			Location struct {
+				Href string `xml:"href,attr"`
+			} `xml:"location"`
+		} `xml:"data"`
+	}{}
+	if err := xml.Unmarshal(body, repomd); err != nil {
+		return "", fmt.Errorf("primaryLocationFromRepomd: %w", err)
+	}
+
+	for _, v := range repomd.Data {
+		if v.Type == "primary" {
+			return v.Location.Href, nil
+		}
+	}
+
+	return "", &utils.ContextError{
+		Context: "primaryLocationFromRepomd",
+		Err:     fmt.Errorf("error: unable to find primary metadata location in repomd.xml"),
+	}
+}
+
+// packageLocationsFromPrimary collects package locations from primary.xml data.
+// Metadata is decoded package by package to avoid loading it all in memory
+func packageLocationsFromPrimary(r io.Reader) (yumIndex, error) {
+	index := make(yumIndex)
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("packageLocationsFromPrimary: %w", err)
+		}
+
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "package" {
+			continue
+		}
+
+		pkg := &struct {
+			Location struct {
+				Href string `xml:"href,attr"`
+			} `xml:"location"`
+		}{}
+		if err := decoder.DecodeElement(pkg, &start); err != nil {
+			return nil, fmt.Errorf("packageLocationsFromPrimary: %w", err)
+		}
+
+		// The first location is kept if package is listed twice
+		if fileName := path.Base(pkg.Location.Href); index[fileName] == "" {
+			index[fileName] = pkg.Location.Href
+		}
+	}
+	return index, nil
+}
+
+// packageLocation search package location in repository index
+func (y Yum) packageLocation(index yumIndex) (string, error) {
+	if location, ok := index[y.FileName]; ok {
+		return location, nil
+	}
+	return "", &utils.ContextError{
+		Context: "packageLocation",
+		Err:     fmt.Errorf("error: unable to find package: %s at: %s", y.FileName, y.Server),
+	}
+}
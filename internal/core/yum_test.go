@@ -0,0 +1,133 @@
+package core
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func Test_primaryLocationFromRepomd(t *testing.T) {
+	const repomd = `<?xml version="1.0" encoding="UTF-8"?>
+<repomd xmlns="http://linux.duke.edu/metadata/repo">
+  <data type="filelists">
+    <location href="repodata/123-filelists.xml.gz"/>
+  </data>
+  <data type="primary">
+    <location href="repodata/456-primary.xml.gz"/>
+  </data>
+</repomd>`
+
+	got, err := primaryLocationFromRepomd(strings.NewReader(repomd))
+	if err != nil {
+		t.Errorf("primaryLocationFromRepomd() error = %v", err)
+		return
+	}
+	if want := "repodata/456-primary.xml.gz"; got != want {
+		t.Errorf("primaryLocationFromRepomd() = %v, want %v", got, want)
+	}
+}
+
+func TestYum_packageLocation(t *testing.T) {
+	const primary = `<?xml version="1.0" encoding="UTF-8"?>
+<metadata xmlns="http://linux.duke.edu/metadata/common" packages="2">
+  <package type="rpm">
+    <name>bash</name>
+    <location href="Packages/bash-4.2.46-34.el7.x86_64.rpm"/>
+  </package>
+  <package type="rpm">
+    <name>curl</name>
+    <location href="Packages/c/curl-7.29.0-59.el7.x86_64.rpm"/>
+  </package>
+</metadata>`
+
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		wantErr  bool
+	}{
+		{name: "test1", fileName: "curl-7.29.0-59.el7.x86_64.rpm", want: "Packages/c/curl-7.29.0-59.el7.x86_64.rpm"},
+		{name: "test2", fileName: "bash-4.2.46-34.el7.x86_64.rpm", want: "Packages/bash-4.2.46-34.el7.x86_64.rpm"},
+		{name: "test3", fileName: "zsh-5.0.2-34.el7.x86_64.rpm", wantErr: true},
+	}
+	index, err := packageLocationsFromPrimary(strings.NewReader(primary))
+	if err != nil {
+		t.Fatalf("packageLocationsFromPrimary() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			y := Yum{FileName: tt.fileName}
+			got, err := y.packageLocation(index)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("packageLocation() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("packageLocation() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestYum_assetDownloadURL_indexCache(t *testing.T) {
+	const repomd = `<repomd><data type="primary"><location href="repodata/primary.xml"/></data></repomd>`
+	const primary = `<metadata>
+  <package type="rpm"><location href="Packages/bash-4.2.46-34.el7.x86_64.rpm"/></package>
+  <package type="rpm"><location href="Packages/curl-7.29.0-59.el7.x86_64.rpm"/></package>
+</metadata>`
+	var mu sync.Mutex
+	requests := make(map[string]int)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests[r.URL.Path]++
+		mu.Unlock()
+		switch r.URL.Path {
+		case "/repodata/repomd.xml":
+			_, _ = w.Write([]byte(repomd))
+		case "/repodata/primary.xml":
+			_, _ = w.Write([]byte(primary))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer upstream.Close()
+
+	// Metadata is downloaded once for all packages of job
+	ctx := withIndexCache(context.Background())
+	var wg sync.WaitGroup
+	for _, v := range []string{"bash-4.2.46-34.el7.x86_64.rpm", "curl-7.29.0-59.el7.x86_64.rpm"} {
+		wg.Add(1)
+		go func(fileName string) {
+			defer wg.Done()
+			y := NewYum(NewUpstream(upstream.URL), "Packages/"+fileName, fileName)
+			if got, err := y.assetDownloadURL(ctx); err != nil || got != upstream.URL+"/Packages/"+fileName {
+				t.Errorf("assetDownloadURL() = %v, error = %v", got, err)
+			}
+		}(v)
+	}
+	wg.Wait()
+	if requests["/repodata/repomd.xml"] != 1 || requests["/repodata/primary.xml"] != 1 {
+		t.Errorf("assetDownloadURL() metadata requests = %v, want one of each", requests)
+	}
+}
+
+func TestYum_directory(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		want string
+	}{
+		{name: "test1", path: "7/os/x86_64/Packages/bash-4.2.46-34.el7.x86_64.rpm", want: "7/os/x86_64/Packages"},
+		{name: "test2", path: "/bash-4.2.46-34.el7.x86_64.rpm", want: "/"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := (Yum{Path: tt.path}).directory(); got != tt.want {
+				t.Errorf("directory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
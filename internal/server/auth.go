@@ -1,12 +1,14 @@
 package server
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"github.com/golang-jwt/jwt"
 	log "github.com/sirupsen/logrus"
 	"math/big"
+	"net"
 	"net/http"
 	"nexus-pusher/internal/config"
 	"nexus-pusher/pkg/utils"
@@ -26,21 +28,34 @@ type Claims struct {
 func (u *webService) signInMiddle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		var credentials Credentials
-		// Get credentials from request
-		user, pass, ok := r.BasicAuth()
-		if !ok {
-			log.Errorf("no basic auth found in request")
-			w.WriteHeader(http.StatusUnauthorized)
-			return
-		}
-		credentials.Username = user
-		credentials.Password = pass
-
-		// Get the expected password from our in memory map
-		if expectedPassword, ok := u.cfg.Credentials[credentials.Username]; !ok || expectedPassword != credentials.Password {
-			log.Errorf("wrong password provided for username '%s'", credentials.Username)
-			w.WriteHeader(http.StatusUnauthorized)
-			return
+		// Client authenticated with verified certificate doesn't need a password
+		if credentials.Username = certUser(r); credentials.Username == "" {
+			// Get credentials from request
+			user, pass, ok := r.BasicAuth()
+			if !ok {
+				log.Errorf("no basic auth found in request")
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			credentials.Username = user
+			credentials.Password = pass
+
+			// Block client which reached failed logins limit
+			ip := clientIP(r)
+			if u.logins.blocked(ip, credentials.Username, time.Now()) {
+				log.Errorf("too many failed logins for username '%s' from %s", credentials.Username, ip)
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+
+			// Check password against stored password or its hash
+			if !u.credentials.check(credentials.Username, credentials.Password) {
+				u.logins.failed(ip, credentials.Username, time.Now())
+				log.Errorf("wrong password provided for username '%s'", credentials.Username)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			u.logins.succeeded(ip, credentials.Username)
 		}
 
 		// Declare the expiration time of the token as 5 minutes
@@ -54,10 +69,8 @@ func (u *webService) signInMiddle(next http.Handler) http.Handler {
 			},
 		}
 
-		// Declare the token with the algorithm used for signing, and the claims
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		// Create the JWT string
-		tokenString, err := token.SignedString(u.jwtKey)
+		// Create the JWT string signed with current signing key
+		tokenString, err := u.jwtKeys.sign(claims)
 		if err != nil {
 			// If there is an error in creating the JWT return an internal server error
 			log.Errorf("unable to create JWT token for user '%s'", credentials.Username)
@@ -80,12 +93,19 @@ func (u *webService) signInMiddle(next http.Handler) http.Handler {
 func (u *webService) authMiddle(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		// Try to auth with client Cookie
-		if _, err := u.authWithCookie(w, r); err != nil {
+		claims, err := u.authWithCookie(w, r)
+		if err != nil {
 			log.Errorf("%v", err)
 			return
 		}
-		// Serve original request
-		next.ServeHTTP(w, r)
+		// Token must be issued to the owner of client certificate
+		if user := certUser(r); user != "" && user != claims.Username {
+			log.Errorf("token of user '%s' is used with certificate of user '%s'", claims.Username, user)
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		// Serve original request on behalf of authenticated user
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, claims.Username)))
 	})
 }
 
@@ -109,8 +129,7 @@ func (u *webService) refreshMiddle(next http.Handler) http.Handler {
 		// Create a new token for the current use, with a renewed expiration time
 		expirationTime := time.Now().Add(config.JWTTokenTTL * time.Minute)
 		claims.ExpiresAt = expirationTime.Unix()
-		token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-		tokenString, err := token.SignedString(u.jwtKey)
+		tokenString, err := u.jwtKeys.sign(claims)
 		if err != nil {
 			// If there is an error in creating the JWT return an internal server error
 			log.Errorf("unable to create JWT token for user '%s'", claims.Username)
@@ -129,6 +148,24 @@ func (u *webService) refreshMiddle(next http.Handler) http.Handler {
 	})
 }
 
+// clientIP returns request client ip address
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// userContextKey is a request context key holding authenticated username
+type userContextKey struct{}
+
+// requestUser returns name of user authenticated the request
+func requestUser(r *http.Request) string {
+	user, _ := r.Context().Value(userContextKey{}).(string)
+	return user
+}
+
 func genRandomJWTKey(n int) []byte {
 	var letter = []rune("abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789")
 
@@ -166,10 +203,8 @@ func (u *webService) authWithCookie(w http.ResponseWriter, r *http.Request) (*Cl
 	claims := &Claims{}
 
 	// Parse the JWT string and store the result in `claims`.
-	tkn, err := jwt.ParseWithClaims(tknStr, claims,
-		func(token *jwt.Token) (interface{}, error) {
-			return u.jwtKey, nil
-		})
+	// Token is verified with any of active keys following its key id
+	tkn, err := jwt.ParseWithClaims(tknStr, claims, u.jwtKeys.keyFunc)
 	if err != nil {
 		if errors.Is(err, jwt.ErrSignatureInvalid) {
 			log.Errorf("can't parse JWT string. %v", err)
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"fmt"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"nexus-pusher/pkg/utils"
+	"path"
+	"strings"
+)
+
+// authorizeUpload checks whether user is allowed to upload components to repository of destination server
+func authorizeUpload(cfg *config.Server, user string, nec *core.NexusExportComponents, repo string) error {
+	if len(cfg.Authorization) == 0 {
+		return nil
+	}
+	perms, ok := cfg.Authorization[user]
+	if !ok {
+		return &utils.ContextError{
+			Context: "authorizeUpload",
+			Err:     fmt.Errorf("user '%s' has no upload permissions", user),
+		}
+	}
+	if !matchAny(perms.Hosts, func(v string) bool { return sameHost(v, nec.NexusServer.Host) }) {
+		return &utils.ContextError{
+			Context: "authorizeUpload",
+			Err:     fmt.Errorf("user '%s' is not allowed to upload to host %s", user, nec.NexusServer.Host),
+		}
+	}
+	if !matchAny(perms.Repositories, func(v string) bool {
+		ok, err := path.Match(v, repo)
+		return err == nil && ok
+	}) {
+		return &utils.ContextError{
+			Context: "authorizeUpload",
+			Err:     fmt.Errorf("user '%s' is not allowed to upload to '%s' repository", user, repo),
+		}
+	}
+	for _, c := range nec.Items {
+		if !matchAny(perms.Formats, func(v string) bool { return strings.EqualFold(v, c.Format) }) {
+			return &utils.ContextError{
+				Context: "authorizeUpload",
+				Err:     fmt.Errorf("user '%s' is not allowed to upload '%s' format components", user, c.Format),
+			}
+		}
+	}
+	return nil
+}
+
+// authorizeJobs check user is allowed to see jobs of owner. Admin may see jobs of all users
+func authorizeJobs(cfg *config.Server, user string, owner string) error {
+	if user == owner || cfg.Authorization[user].Admin {
+		return nil
+	}
+	return &utils.ContextError{
+		Context: "authorizeJobs",
+		Err:     fmt.Errorf("user '%s' is not allowed to see jobs of user '%s'", user, owner),
+	}
+}
+
+// matchAny reports whether any of values is matching. Empty values are matching anything
+func matchAny(values []string, match func(v string) bool) bool {
+	if len(values) == 0 {
+		return true
+	}
+	for _, v := range values {
+		if match(v) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"github.com/goccy/go-json"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_authorizeUpload(t *testing.T) {
+	cfg := &config.Server{Authorization: map[string]config.UserPermissions{
+		"team1": {
+			Hosts:        []string{"https://nexus1.some"},
+			Repositories: []string{"npm-*", "maven-releases"},
+			Formats:      []string{"npm", "maven2"},
+		},
+		"admin": {},
+	}}
+	newComponents := func(host string, formats ...string) *core.NexusExportComponents {
+		nec := &core.NexusExportComponents{NexusServer: core.NexusServer{Host: host}}
+		for _, v := range formats {
+			nec.Items = append(nec.Items, &core.NexusExportComponent{Format: v})
+		}
+		return nec
+	}
+	tests := []struct {
+		name    string
+		cfg     *config.Server
+		user    string
+		nec     *core.NexusExportComponents
+		repo    string
+		wantErr string
+	}{
+		{
+			name: "allowed",
+			cfg:  cfg,
+			user: "team1",
+			nec:  newComponents("https://nexus1.some/", "npm", "NPM"),
+			repo: "npm-proxy",
+		},
+		{
+			name:    "not allowed host",
+			cfg:     cfg,
+			user:    "team1",
+			nec:     newComponents("https://nexus2.some", "npm"),
+			repo:    "npm-proxy",
+			wantErr: "not allowed to upload to host",
+		},
+		{
+			name:    "not allowed repository",
+			cfg:     cfg,
+			user:    "team1",
+			nec:     newComponents("https://nexus1.some", "maven2"),
+			repo:    "maven-snapshots",
+			wantErr: "not allowed to upload to 'maven-snapshots' repository",
+		},
+		{
+			name:    "not allowed format",
+			cfg:     cfg,
+			user:    "team1",
+			nec:     newComponents("https://nexus1.some", "npm", "docker"),
+			repo:    "npm-proxy",
+			wantErr: "not allowed to upload 'docker' format components",
+		},
+		{
+			name:    "unknown user",
+			cfg:     cfg,
+			user:    "team2",
+			nec:     newComponents("https://nexus1.some", "npm"),
+			repo:    "npm-proxy",
+			wantErr: "has no upload permissions",
+		},
+		{
+			name: "user without restrictions",
+			cfg:  cfg,
+			user: "admin",
+			nec:  newComponents("https://nexus2.some", "docker"),
+			repo: "docker-hosted",
+		},
+		{
+			name: "authorization is not configured",
+			cfg:  &config.Server{},
+			user: "team2",
+			nec:  newComponents("https://nexus2.some", "docker"),
+			repo: "docker-hosted",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := authorizeUpload(tt.cfg, tt.user, tt.nec, tt.repo)
+			if tt.wantErr == "" && err != nil {
+				t.Errorf("authorizeUpload() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("authorizeUpload() error = %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_webService_components_forbidden(t *testing.T) {
+	cfg := &config.Server{Authorization: map[string]config.UserPermissions{
+		"team1": {Repositories: []string{"npm-*"}},
+	}}
+	u := newWebService(cfg, newJobRegistry(time.Hour), &memoryJobStore{}, nil, nil)
+	body, err := json.Marshal(&core.NexusExportComponents{
+		NexusServer: core.NexusServer{Host: "https://nexus.some"},
+		Items:       []*core.NexusExportComponent{{Name: "pkg", Version: "1.0.0", Format: "npm"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest("POST", config.URIBase+config.URIComponents+"?repository=maven-releases",
+		bytes.NewReader(body))
+	req = req.WithContext(context.WithValue(req.Context(), userContextKey{}, "team1"))
+	w := httptest.NewRecorder()
+	u.components(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("components() status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if !strings.Contains(w.Body.String(), "not allowed to upload to 'maven-releases' repository") {
+		t.Errorf("components() body = %s", w.Body.String())
+	}
+	if got := u.jobs.list(jobFilter{}); len(got) != 0 {
+		t.Errorf("components() forbidden job was created")
+	}
+}
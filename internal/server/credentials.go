@@ -0,0 +1,246 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"nexus-pusher/pkg/utils"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// dummyPasswordHash is a bcrypt hash (default cost) checked for unknown users
+const dummyPasswordHash = "$2a$10$eixBZpJDiS9ihBoegZhnw.FxumOifwvbVpohfv5HRERRaSH2VZKfm"
+
+// credentialStore is holding server users passwords (or their hashes) from config and optional htpasswd file
+type credentialStore struct {
+	mu     sync.RWMutex
+	static map[string]string
+	// htpasswd file is re-read when it's changed
+	path    string
+	modTime time.Time
+	file    map[string]string
+}
+
+func newCredentialStore(static map[string]string, htpasswdPath string) *credentialStore {
+	return &credentialStore{static: static, path: htpasswdPath}
+}
+
+// check reports whether password is valid for user. Users of htpasswd file take precedence over config ones
+func (c *credentialStore) check(user string, pass string) bool {
+	if err := c.reload(); err != nil {
+		log.Errorf("%v", err)
+	}
+	c.mu.RLock()
+	stored, ok := c.file[user]
+	if !ok {
+		stored, ok = c.static[user]
+	}
+	c.mu.RUnlock()
+	if !ok {
+		// Spend the same time as for known user, so response time doesn't reveal existing usernames
+		verifyPassword(dummyPasswordHash, pass)
+		return false
+	}
+	return verifyPassword(stored, pass)
+}
+
+// reload will read htpasswd file if it was changed since last read
+func (c *credentialStore) reload() error {
+	if c.path == "" {
+		return nil
+	}
+	info, err := os.Stat(c.path)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	c.mu.RLock()
+	changed := !info.ModTime().Equal(c.modTime)
+	c.mu.RUnlock()
+	if !changed {
+		return nil
+	}
+
+	data, err := ioutil.ReadFile(c.path)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	users, err := parseHtpasswd(data)
+	if err != nil {
+		return fmt.Errorf("reload: %w", err)
+	}
+	c.mu.Lock()
+	c.file = users
+	c.modTime = info.ModTime()
+	c.mu.Unlock()
+	log.Infof("Loaded %d users from htpasswd file %s", len(users), c.path)
+	return nil
+}
+
+// parseHtpasswd will parse Apache htpasswd file. Only bcrypt and argon2 hashes are supported
+func parseHtpasswd(data []byte) (map[string]string, error) {
+	users := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		i := strings.Index(text, ":")
+		if i <= 0 {
+			return nil, &utils.ContextError{
+				Context: "parseHtpasswd",
+				Err:     fmt.Errorf("wrong format at line %d", line),
+			}
+		}
+		user, hash := text[:i], text[i+1:]
+		if !isBcryptHash(hash) && !isArgon2Hash(hash) {
+			log.Warnf("skipping user '%s' at line %d of htpasswd file: only bcrypt and argon2 hashes are supported",
+				user, line)
+			continue
+		}
+		users[user] = hash
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parseHtpasswd: %w", err)
+	}
+	return users, nil
+}
+
+func isBcryptHash(s string) bool {
+	return strings.HasPrefix(s, "$2a$") || strings.HasPrefix(s, "$2b$") || strings.HasPrefix(s, "$2y$")
+}
+
+func isArgon2Hash(s string) bool {
+	return strings.HasPrefix(s, "$argon2id$") || strings.HasPrefix(s, "$argon2i$")
+}
+
+// verifyPassword will compare password with stored bcrypt or argon2 hash in constant time.
+// Stored value which is not a hash is compared as a plain password
+func verifyPassword(stored string, pass string) bool {
+	switch {
+	case isBcryptHash(stored):
+		return bcrypt.CompareHashAndPassword([]byte(stored), []byte(pass)) == nil
+	case isArgon2Hash(stored):
+		ok, err := verifyArgon2(stored, pass)
+		if err != nil {
+			log.Errorf("%v", err)
+		}
+		return ok
+	default:
+		return subtle.ConstantTimeCompare([]byte(stored), []byte(pass)) == 1
+	}
+}
+
+// verifyArgon2 will compare password with argon2 hash in PHC string format,
+// i.e. '$argon2id$v=19$m=65536,t=3,p=4$<salt>$<hash>'
+func verifyArgon2(stored string, pass string) (bool, error) {
+	parts := strings.Split(stored, "$")
+	if len(parts) != 6 {
+		return false, &utils.ContextError{
+			Context: "verifyArgon2",
+			Err:     fmt.Errorf("wrong argon2 hash format"),
+		}
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil {
+		return false, fmt.Errorf("verifyArgon2: %w", err)
+	}
+	if version != argon2.Version {
+		return false, &utils.ContextError{
+			Context: "verifyArgon2",
+			Err:     fmt.Errorf("unsupported argon2 version %d", version),
+		}
+	}
+	var memory, iterations uint32
+	var threads uint8
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &threads); err != nil {
+		return false, fmt.Errorf("verifyArgon2: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return false, fmt.Errorf("verifyArgon2: %w", err)
+	}
+	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return false, fmt.Errorf("verifyArgon2: %w", err)
+	}
+
+	var key []byte
+	if parts[1] == "argon2id" {
+		key = argon2.IDKey([]byte(pass), salt, iterations, memory, threads, uint32(len(hash)))
+	} else {
+		key = argon2.Key([]byte(pass), salt, iterations, memory, threads, uint32(len(hash)))
+	}
+	return subtle.ConstantTimeCompare(key, hash) == 1, nil
+}
+
+// loginLimiter is limiting failed logins per client ip and username
+type loginLimiter struct {
+	mu       sync.Mutex
+	failures map[string]*loginFailures
+	// Client is blocked after max failed logins until lockout time passed since first failure
+	max     int
+	lockout time.Duration
+}
+
+type loginFailures struct {
+	count int
+	first time.Time
+}
+
+func newLoginLimiter(max int, lockout time.Duration) *loginLimiter {
+	return &loginLimiter{failures: make(map[string]*loginFailures), max: max, lockout: lockout}
+}
+
+func loginKey(ip string, user string) string {
+	return ip + "|" + user
+}
+
+// blocked reports whether client reached failed logins limit
+func (l *loginLimiter) blocked(ip string, user string, now time.Time) bool {
+	if l.max <= 0 {
+		return false
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	f, ok := l.failures[loginKey(ip, user)]
+	return ok && f.count >= l.max && now.Sub(f.first) < l.lockout
+}
+
+// failed will record failed login of client
+func (l *loginLimiter) failed(ip string, user string, now time.Time) {
+	if l.max <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// Drop expired failures
+	for k, v := range l.failures {
+		if now.Sub(v.first) >= l.lockout {
+			delete(l.failures, k)
+		}
+	}
+	key := loginKey(ip, user)
+	f, ok := l.failures[key]
+	if !ok {
+		f = &loginFailures{first: now}
+		l.failures[key] = f
+	}
+	f.count++
+}
+
+// succeeded will reset failed logins of client
+func (l *loginLimiter) succeeded(ip string, user string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.failures, loginKey(ip, user))
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"encoding/base64"
+	"fmt"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func argon2Hash(pass string) string {
+	salt := []byte("somesalt")
+	key := argon2.IDKey([]byte(pass), salt, 1, 64, 1, 32)
+	return fmt.Sprintf("$argon2id$v=%d$m=64,t=1,p=1$%s$%s", argon2.Version,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key))
+}
+
+func Test_verifyPassword(t *testing.T) {
+	bcryptHash, err := bcrypt.GenerateFromPassword([]byte("pass"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		stored string
+		pass   string
+		want   bool
+	}{
+		{"bcrypt", string(bcryptHash), "pass", true},
+		{"bcrypt wrong password", string(bcryptHash), "wrong", false},
+		{"argon2id", argon2Hash("pass"), "pass", true},
+		{"argon2id wrong password", argon2Hash("pass"), "wrong", false},
+		{"broken argon2id", "$argon2id$v=19$m=64", "pass", false},
+		{"plain", "pass", "pass", true},
+		{"plain wrong password", "pass", "pass1", false},
+		// Hash of unknown users must be verified as bcrypt hash to spend the same time
+		{"dummy hash", dummyPasswordHash, "", false},
+	}
+	if _, err := bcrypt.Cost([]byte(dummyPasswordHash)); err != nil || !isBcryptHash(dummyPasswordHash) {
+		t.Errorf("dummyPasswordHash is not valid bcrypt hash: %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPassword(tt.stored, tt.pass); got != tt.want {
+				t.Errorf("verifyPassword() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_credentialStore_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "htpasswd")
+	hash, err := bcrypt.GenerateFromPassword([]byte("pass1"), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Plain password is not supported in htpasswd file
+	if err := ioutil.WriteFile(path, []byte("# users\nuser1:"+string(hash)+"\nuser2:pass2\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	c := newCredentialStore(map[string]string{"user1": "static", "user3": "pass3"}, path)
+	if !c.check("user1", "pass1") || c.check("user1", "static") {
+		t.Errorf("check() htpasswd file user must take precedence")
+	}
+	if c.check("user2", "pass2") {
+		t.Errorf("check() user with plain password in htpasswd file must be skipped")
+	}
+	if !c.check("user3", "pass3") {
+		t.Errorf("check() config user must be valid")
+	}
+
+	// Changed file is re-read
+	if err := ioutil.WriteFile(path, []byte("user2:"+argon2Hash("pass2")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	modTime := time.Now().Add(time.Second)
+	if err := os.Chtimes(path, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+	if !c.check("user2", "pass2") || c.check("user1", "pass1") {
+		t.Errorf("check() htpasswd file was not reloaded")
+	}
+
+	// Last loaded users are kept if file can't be read
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if !c.check("user2", "pass2") {
+		t.Errorf("check() users of removed htpasswd file must be kept")
+	}
+}
+
+func Test_loginLimiter(t *testing.T) {
+	l := newLoginLimiter(2, time.Minute)
+	now := time.Now()
+	l.failed("10.0.0.1", "user1", now)
+	if l.blocked("10.0.0.1", "user1", now) {
+		t.Errorf("blocked() before limit is reached")
+	}
+	l.failed("10.0.0.1", "user1", now)
+	if !l.blocked("10.0.0.1", "user1", now) {
+		t.Errorf("blocked() after limit is reached")
+	}
+	// Limit is counted per ip and username
+	if l.blocked("10.0.0.2", "user1", now) || l.blocked("10.0.0.1", "user2", now) {
+		t.Errorf("blocked() another client")
+	}
+	if l.blocked("10.0.0.1", "user1", now.Add(time.Minute)) {
+		t.Errorf("blocked() after lockout time")
+	}
+	l.succeeded("10.0.0.1", "user1")
+	if l.blocked("10.0.0.1", "user1", now) {
+		t.Errorf("blocked() after successful login")
+	}
+}
+
+func Test_webService_signInMiddle(t *testing.T) {
+	cfg := &config.Server{Credentials: map[string]string{"user1": "pass"}}
+	cfg.Auth.MaxFailedLogins = 2
+	cfg.Auth.LockoutMinutes = 1
+	u := newWebService(cfg, newJobRegistry(time.Hour), &memoryJobStore{}, newHMACKeySet([]byte("key")), nil)
+	login := func(pass string) int {
+		req := httptest.NewRequest("GET", config.URIBase+config.URILogin, nil)
+		req.SetBasicAuth("user1", pass)
+		w := httptest.NewRecorder()
+		u.signInMiddle(http.HandlerFunc(stub)).ServeHTTP(w, req)
+		return w.Code
+	}
+	for i, want := range []int{http.StatusUnauthorized, http.StatusUnauthorized, http.StatusTooManyRequests} {
+		if got := login("wrong"); got != want {
+			t.Errorf("signInMiddle() attempt %d status = %d, want %d", i+1, got, want)
+		}
+	}
+	// Valid password is rejected too while client is blocked
+	if got := login("pass"); got != http.StatusTooManyRequests {
+		t.Errorf("signInMiddle() status = %d, want %d", got, http.StatusTooManyRequests)
+	}
+}
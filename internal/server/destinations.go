@@ -0,0 +1,137 @@
+package server
+
+import (
+	"fmt"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"nexus-pusher/pkg/utils"
+	"strings"
+)
+
+// isAllowedHost reports whether destination host is allowed by server config.
+// Any host is allowed if there are no allowed hosts and profiles configured
+func isAllowedHost(cfg *config.Server, host string) bool {
+	dst := cfg.Destinations
+	if len(dst.AllowedHosts) == 0 && len(dst.Profiles) == 0 {
+		return true
+	}
+	for _, v := range dst.AllowedHosts {
+		if sameHost(v, host) {
+			return true
+		}
+	}
+	for _, v := range dst.Profiles {
+		if sameHost(v.Host, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func sameHost(a string, b string) bool {
+	return strings.EqualFold(strings.TrimRight(a, "/"), strings.TrimRight(b, "/"))
+}
+
+// checkDestination will validate destination server of client request. Host of profile is set if it's missing
+func checkDestination(cfg *config.Server, s *core.NexusServer) error {
+	if s.Profile != "" {
+		profile, ok := cfg.Destinations.Profiles[s.Profile]
+		if !ok {
+			return &utils.ContextError{
+				Context: "checkDestination",
+				Err:     fmt.Errorf("unknown destination profile '%s'", s.Profile),
+			}
+		}
+		if s.Host == "" {
+			s.Host = profile.Host
+		}
+		if !sameHost(s.Host, profile.Host) {
+			return &utils.ContextError{
+				Context: "checkDestination",
+				Err:     fmt.Errorf("destination host %s doesn't match '%s' profile host", s.Host, s.Profile),
+			}
+		}
+		// Profile credentials are sent to docker connector too, so only connector of profile is used
+		if s.DockerConnector != "" && !sameHost(s.DockerConnector, profile.DockerConnector) {
+			return &utils.ContextError{
+				Context: "checkDestination",
+				Err: fmt.Errorf("docker connector %s doesn't match '%s' profile docker connector",
+					s.DockerConnector, s.Profile),
+			}
+		}
+		return nil
+	}
+	if !isAllowedHost(cfg, s.Host) {
+		return &utils.ContextError{
+			Context: "checkDestination",
+			Err:     fmt.Errorf("destination host %s is not allowed", s.Host),
+		}
+	}
+	if s.DockerConnector != "" && !isAllowedHost(cfg, s.DockerConnector) {
+		return &utils.ContextError{
+			Context: "checkDestination",
+			Err:     fmt.Errorf("docker connector %s is not allowed", s.DockerConnector),
+		}
+	}
+	return nil
+}
+
+// resolveDestination returns destination server with credentials and TLS options of its profile.
+// Connection options and credentials of configured upstreams are set too
+func resolveDestination(cfg *config.Server, s core.NexusServer) (*core.NexusServer, error) {
+	if err := checkDestination(cfg, &s); err != nil {
+		return nil, fmt.Errorf("resolveDestination: %w", err)
+	}
+	if profile, ok := cfg.Destinations.Profiles[s.Profile]; ok && s.Profile != "" {
+		s.Host = profile.Host
+		s.Username = profile.User
+		s.Password = profile.Pass
+		s.DockerConnector = profile.DockerConnector
+		tlsConfig, err := core.TLSConfig(profile.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("resolveDestination: profile '%s': %w", s.Profile, err)
+		}
+		s.TLS = tlsConfig
+	}
+	for _, v := range cfg.Upstreams {
+		tlsConfig, err := core.TLSConfig(v.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("resolveDestination: upstream %s: %w", v.URL, err)
+		}
+		auth, err := core.NewUpstreamAuth(v.URL, v.Auth)
+		if err != nil {
+			return nil, fmt.Errorf("resolveDestination: upstream %s: %w", v.URL, err)
+		}
+		s.Upstreams = append(s.Upstreams, &core.Upstream{URL: v.URL, TLS: tlsConfig, Auth: auth})
+	}
+	return &s, nil
+}
+
+// restoreCredentials check credentials of job restored from store can be taken from server config.
+// Destination password and artifacts sources credentials sent by client are never stored,
+// so destination profile and configured upstreams credentials are used instead of them
+func restoreCredentials(cfg *config.Server, nec *core.NexusExportComponents) error {
+	if nec.NexusServer.Profile == "" && nec.NexusServer.Username != "" {
+		return &utils.ContextError{
+			Context: "restoreCredentials",
+			Err: fmt.Errorf("credentials of destination %s sent by client are not stored, request must be sent again",
+				nec.NexusServer.Host),
+		}
+	}
+	s, err := resolveDestination(cfg, nec.NexusServer)
+	if err != nil {
+		return fmt.Errorf("restoreCredentials: %w", err)
+	}
+	for _, v := range nec.Upstreams {
+		if !s.HasUpstreamAuth(v.URL) {
+			return &utils.ContextError{
+				Context: "restoreCredentials",
+				Err: fmt.Errorf("credentials of artifacts source %s sent by client are not stored, request must be sent again",
+					v.URL),
+			}
+		}
+	}
+	// Upstreams without credentials would override configured ones
+	nec.Upstreams = nil
+	return nil
+}
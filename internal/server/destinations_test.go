@@ -0,0 +1,182 @@
+package server
+
+import (
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"reflect"
+	"testing"
+)
+
+func Test_checkDestination(t *testing.T) {
+	cfg := &config.Server{Destinations: config.Destinations{
+		AllowedHosts: []string{"https://nexus1.some/", "https://docker1.some"},
+		Profiles: map[string]config.DestinationProfile{
+			"nexus2": {Host: "https://nexus2.some", User: "admin", Pass: "secret", DockerConnector: "https://docker2.some"},
+		},
+	}}
+	tests := []struct {
+		name     string
+		cfg      *config.Server
+		server   core.NexusServer
+		wantHost string
+		wantErr  bool
+	}{
+		{
+			name:     "allowed host",
+			cfg:      cfg,
+			server:   core.NexusServer{Host: "https://NEXUS1.some", Username: "user", Password: "pass"},
+			wantHost: "https://NEXUS1.some",
+		},
+		{
+			name:     "host of profile",
+			cfg:      cfg,
+			server:   core.NexusServer{Host: "https://nexus2.some/", Username: "user", Password: "pass"},
+			wantHost: "https://nexus2.some/",
+		},
+		{
+			name:    "not allowed host",
+			cfg:     cfg,
+			server:  core.NexusServer{Host: "https://nexus3.some"},
+			wantErr: true,
+		},
+		{
+			name:     "profile without host",
+			cfg:      cfg,
+			server:   core.NexusServer{Profile: "nexus2"},
+			wantHost: "https://nexus2.some",
+		},
+		{
+			name:    "profile with another host",
+			cfg:     cfg,
+			server:  core.NexusServer{Host: "https://nexus1.some", Profile: "nexus2"},
+			wantErr: true,
+		},
+		{
+			name:     "allowed docker connector",
+			cfg:      cfg,
+			server:   core.NexusServer{Host: "https://nexus1.some", DockerConnector: "https://docker1.some/"},
+			wantHost: "https://nexus1.some",
+		},
+		{
+			name:    "not allowed docker connector",
+			cfg:     cfg,
+			server:  core.NexusServer{Host: "https://nexus1.some", DockerConnector: "https://docker3.some"},
+			wantErr: true,
+		},
+		{
+			name:     "docker connector of profile",
+			cfg:      cfg,
+			server:   core.NexusServer{Profile: "nexus2", DockerConnector: "https://docker2.some"},
+			wantHost: "https://nexus2.some",
+		},
+		{
+			name:    "profile with another docker connector",
+			cfg:     cfg,
+			server:  core.NexusServer{Profile: "nexus2", DockerConnector: "https://docker3.some"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown profile",
+			cfg:     cfg,
+			server:  core.NexusServer{Profile: "nexus1"},
+			wantErr: true,
+		},
+		{
+			name:     "any host without destinations config",
+			cfg:      &config.Server{},
+			server:   core.NexusServer{Host: "https://nexus3.some"},
+			wantHost: "https://nexus3.some",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.server
+			err := checkDestination(tt.cfg, &s)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkDestination() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && s.Host != tt.wantHost {
+				t.Errorf("checkDestination() host = %s, want %s", s.Host, tt.wantHost)
+			}
+		})
+	}
+}
+
+func Test_resolveDestination(t *testing.T) {
+	cfg := &config.Server{
+		Destinations: config.Destinations{
+			Profiles: map[string]config.DestinationProfile{
+				"nexus": {Host: "https://nexus.some", User: "admin", Pass: "secret", DockerConnector: "https://docker.some",
+					TLS: config.TLSClient{ServerName: "nexus.internal"}},
+				"broken": {Host: "https://broken.some", User: "admin", Pass: "secret",
+					TLS: config.TLSClient{CAPath: "/not/existing/ca.pem"}},
+			},
+		},
+		Upstreams: []config.Upstream{{URL: "https://npm.some", TLS: config.TLSClient{InsecureSkipVerify: true}}},
+	}
+	got, err := resolveDestination(cfg, core.NexusServer{Host: "https://nexus.some/", BaseUrl: "/service/rest", Profile: "nexus"})
+	if err != nil {
+		t.Fatalf("resolveDestination() error = %v", err)
+	}
+	// TLS options of profile and upstreams are set
+	if got.TLS == nil || got.TLS.ServerName != "nexus.internal" {
+		t.Errorf("resolveDestination() TLS = %+v", got.TLS)
+	}
+	if len(got.Upstreams) != 1 || got.Upstreams[0].URL != "https://npm.some" || !got.Upstreams[0].TLS.InsecureSkipVerify {
+		t.Errorf("resolveDestination() Upstreams = %+v", got.Upstreams)
+	}
+	got.TLS, got.Upstreams = nil, nil
+	want := &core.NexusServer{
+		Host:            "https://nexus.some",
+		BaseUrl:         "/service/rest",
+		Username:        "admin",
+		Password:        "secret",
+		DockerConnector: "https://docker.some",
+		Profile:         "nexus",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("resolveDestination() got = %+v, want %+v", got, want)
+	}
+	if _, err := resolveDestination(cfg, core.NexusServer{Host: "https://other.some"}); err == nil {
+		t.Errorf("resolveDestination() of not allowed host must fail")
+	}
+	if _, err := resolveDestination(cfg, core.NexusServer{Profile: "broken"}); err == nil {
+		t.Errorf("resolveDestination() with missing CA bundle must fail")
+	}
+}
+
+func Test_restoreCredentials(t *testing.T) {
+	cfg := &config.Server{
+		Destinations: config.Destinations{
+			Profiles: map[string]config.DestinationProfile{
+				"nexus": {Host: "https://nexus.some", User: "admin", Pass: "secret"},
+			},
+		},
+		Upstreams: []config.Upstream{{URL: "https://npm.corp.some", Auth: config.UpstreamAuth{Token: "token"}}},
+	}
+	profile := core.NexusServer{Host: "https://nexus.some", Profile: "nexus"}
+	tests := []struct {
+		name      string
+		server    core.NexusServer
+		upstreams []*core.Upstream
+		wantErr   bool
+	}{
+		{"profile", profile, nil, false},
+		{"anonymous destination", core.NexusServer{Host: "https://nexus.some"}, nil, false},
+		{"destination password is lost", core.NexusServer{Host: "https://nexus.some", Username: "user"}, nil, true},
+		{"configured upstream credentials", profile, []*core.Upstream{{URL: "https://npm.corp.some/npm"}}, false},
+		{"upstream credentials are lost", profile, []*core.Upstream{{URL: "https://private.some"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			nec := &core.NexusExportComponents{NexusServer: tt.server, Upstreams: tt.upstreams}
+			err := restoreCredentials(cfg, nec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("restoreCredentials() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && nec.Upstreams != nil {
+				t.Errorf("restoreCredentials() upstreams = %v, want configured ones only", nec.Upstreams)
+			}
+		})
+	}
+}
@@ -8,9 +8,13 @@ import (
 )
 
 func responseError(w http.ResponseWriter, err error, text string) {
+	responseErrorWithCode(w, http.StatusUnprocessableEntity, err, text)
+}
+
+func responseErrorWithCode(w http.ResponseWriter, code int, err error, text string) {
 	errorText := fmt.Sprintf("%s: %s", text, err.Error())
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.WriteHeader(code)
 	if err := json.NewEncoder(w).Encode(errorText); err != nil {
 		log.Errorf("%v", err)
 	}
@@ -4,14 +4,20 @@ import (
 	"fmt"
 	"github.com/goccy/go-json"
 	"github.com/google/uuid"
+	"github.com/gorilla/mux"
 	log "github.com/sirupsen/logrus"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"nexus-pusher/internal/core"
+	"strconv"
 	"strings"
+	"time"
 )
 
+// eventsHeartbeat is an interval of comments sent to keep idle events stream alive
+var eventsHeartbeat = 15 * time.Second
+
 func stub(w http.ResponseWriter, r *http.Request) {
 	_ = r // ignore request here
 	if _, err := fmt.Fprintln(w, "Welcome to nexus-pusher."); err != nil {
@@ -66,88 +72,250 @@ func (u *webService) components(w http.ResponseWriter, r *http.Request) {
 		responseError(w, err, "unable to decode request data")
 		return
 	}
-	// Send response
-	msg, err := u.genMessageWithId()
+	// Check destination server is allowed
+	if err := checkDestination(u.cfg, &nec.NexusServer); err != nil {
+		responseErrorWithCode(w, http.StatusForbidden, err, "forbidden")
+		return
+	}
+	// Check user is allowed to upload to destination repository
+	if err := authorizeUpload(u.cfg, requestUser(r), nec, repo); err != nil {
+		responseErrorWithCode(w, http.StatusForbidden, err, "forbidden")
+		return
+	}
+	// Save new job
+	job, err := u.createJob(nec, repo, requestUser(r))
 	if err != nil {
 		responseError(w, err, "error")
 		return
 	}
 
-	if err := json.NewEncoder(w).Encode(msg); err != nil {
+	// Send response
+	if err := json.NewEncoder(w).Encode(job.Message); err != nil {
 		responseError(w, err, "error message encode")
 		return
 	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
 
 	// Upload components
-	go func() {
-		s := core.NewNexusServer(nec.NexusServer.Username, nec.NexusServer.Password,
-			nec.NexusServer.Host, nec.NexusServer.BaseUrl, nec.NexusServer.ApiComponentsUrl)
-		results := s.UploadComponents(nec, repo, u.cfg)
-
-		var errorsCounter int
-		var errorsText []string
-		for _, v := range results {
-			if v.Err != nil {
-				errorsCounter++
-				errorsText = append(errorsText,
-					fmt.Sprintf("Asset processing error: %s asset=%s", v.Err.Error(), v.ComponentPath))
-			}
-		}
-		if errorsCounter != 0 {
-			log.Warnf("Upload request complete with %d errors:", errorsCounter)
-			for _, v := range errorsText {
-				log.Warnln(v)
-			}
-			// Set complete flag to current client request
-			if err := u.completeById(msg.ID, errorsText); err != nil {
-				log.Errorf("%v", err)
-			}
-		} else {
-			log.Printf("Upload request successfully complete.")
-			// Set complete flag to current client request
-			if err := u.completeById(msg.ID, nil); err != nil {
-				log.Errorf("%v", err)
-			}
-		}
-	}()
+	go u.runJob(job)
 }
 
 func (u *webService) answerMessage(w http.ResponseWriter, r *http.Request) {
+	id, ok := requestUUID(w, r)
+	if !ok {
+		return
+	}
+	if !u.authorizeJob(w, r, id) {
+		return
+	}
+	// Search message by uuid
+	msg, err := u.searchById(id)
+	if err != nil {
+		responseError(w, err, "error")
+		return
+	}
+	// Send response
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		responseError(w, err, "error message encode")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+}
+
+// Cancel upload components request
+func (u *webService) cancelComponents(w http.ResponseWriter, r *http.Request) {
+	id, ok := requestUUID(w, r)
+	if !ok {
+		return
+	}
+	if !u.authorizeJob(w, r, id) {
+		return
+	}
+	if err := u.cancelById(id); err != nil {
+		responseError(w, err, "error")
+		return
+	}
+	log.WithFields(log.Fields{"id": id}).Infof("Upload request cancel requested.")
+	// Send current job state
+	msg, err := u.searchById(id)
+	if err != nil {
+		responseError(w, err, "error")
+		return
+	}
+	if err := json.NewEncoder(w).Encode(msg); err != nil {
+		responseError(w, err, "error message encode")
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+}
+
+// authorizeJob check job is owned by the requesting user or user is admin. Error response is sent if it's not
+func (u *webService) authorizeJob(w http.ResponseWriter, r *http.Request, id uuid.UUID) bool {
+	record, err := u.jobs.record(id)
+	if err != nil {
+		responseError(w, err, "error")
+		return false
+	}
+	if err := authorizeJobs(u.cfg, requestUser(r), record.User); err != nil {
+		responseErrorWithCode(w, http.StatusForbidden, err, "error")
+		return false
+	}
+	return true
+}
+
+// requestUUID will get job id from 'uuid' request parameter. Error response is sent if it's not valid
+func requestUUID(w http.ResponseWriter, r *http.Request) (uuid.UUID, bool) {
 	// Check uuid parameter
 	data := r.URL.Query().Get("uuid")
 	if data == "" {
 		responseError(w, fmt.Errorf("parameter 'uuid' is required"), "error")
-		return
+		return uuid.Nil, false
 	}
 	if _, err := io.Copy(ioutil.Discard, r.Body); err != nil {
 		responseError(w, err, "error")
-		return
+		return uuid.Nil, false
 	}
 	if err := r.Body.Close(); err != nil {
 		responseError(w, err, "error")
-		return
+		return uuid.Nil, false
 	}
 	// Convert string to uuid
 	id, err := uuid.Parse(data)
 	if err != nil {
 		responseError(w, err, "unable to parse uuid")
-		return
+		return uuid.Nil, false
 	}
-	// Search message by uuid
-	msg, err := u.searchById(id)
+	return id, true
+}
+
+// Stream job events (Server-Sent Events) until job is complete
+func (u *webService) jobEvents(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
 	if err != nil {
+		responseError(w, err, "unable to parse uuid")
+		return
+	}
+	// Continue stream after last event received by client
+	var last int
+	if v := r.Header.Get("Last-Event-ID"); v != "" {
+		if last, err = strconv.Atoi(v); err != nil {
+			responseError(w, err, "unable to parse Last-Event-ID")
+			return
+		}
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		responseError(w, fmt.Errorf("streaming is not supported"), "error")
+		return
+	}
+	// Check job before stream is started to respond with error
+	if _, _, err := u.jobs.events(id, last); err != nil {
 		responseError(w, err, "error")
 		return
 	}
-	// Send response
-	if err := json.NewEncoder(w).Encode(msg); err != nil {
+	if !u.authorizeJob(w, r, id) {
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventsHeartbeat)
+	defer heartbeat.Stop()
+	for {
+		events, changed, err := u.jobs.events(id, last)
+		if err != nil {
+			// Job was removed by another client request
+			log.WithFields(log.Fields{"id": id}).Warnf("Events stream closed: %v", err)
+			return
+		}
+		for _, v := range events {
+			if _, err := fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", v.id, v.name, v.data); err != nil {
+				log.Errorf("%v", err)
+				return
+			}
+			last = v.id
+		}
+		flusher.Flush()
+		if len(events) != 0 && events[len(events)-1].name == EventComplete {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case <-changed:
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil {
+				log.Errorf("%v", err)
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
+
+// List jobs following request filters
+func (u *webService) listJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := jobFilter{
+		State:      JobState(query.Get("state")),
+		Host:       query.Get("host"),
+		Repository: query.Get("repository"),
+		User:       query.Get("user"),
+	}
+	if filter.State != "" && !filter.State.Valid() {
+		responseError(w, fmt.Errorf("unknown job state '%s'", filter.State), "error")
+		return
+	}
+	// Jobs of the requesting user are listed unless admin asks for all jobs
+	user := requestUser(r)
+	if filter.User == "" && !u.cfg.Authorization[user].Admin {
+		filter.User = user
+	}
+	if filter.User != "" {
+		if err := authorizeJobs(u.cfg, user, filter.User); err != nil {
+			responseErrorWithCode(w, http.StatusForbidden, err, "error")
+			return
+		}
+	}
+	if v := query.Get("since"); v != "" {
+		since, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			responseError(w, err, "unable to parse 'since' parameter")
+			return
+		}
+		filter.Since = since
+	}
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	if err := json.NewEncoder(w).Encode(u.jobs.list(filter)); err != nil {
 		responseError(w, err, "error message encode")
 		return
 	}
+}
+
+// Get full job record with per-asset errors
+func (u *webService) jobRecord(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		responseError(w, err, "unable to parse uuid")
+		return
+	}
+	record, err := u.jobs.record(id)
+	if err != nil {
+		responseError(w, err, "error")
+		return
+	}
+	if err := authorizeJobs(u.cfg, requestUser(r), record.User); err != nil {
+		responseErrorWithCode(w, http.StatusForbidden, err, "error")
+		return
+	}
 	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
-	// Clear Message data if we complete
-	if msg.Complete {
-		u.deleteById(id)
+	if err := json.NewEncoder(w).Encode(record); err != nil {
+		responseError(w, err, "error message encode")
+		return
 	}
 }
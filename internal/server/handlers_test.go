@@ -0,0 +1,263 @@
+package server
+
+import (
+	"context"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"strings"
+	"testing"
+	"time"
+)
+
+func Test_webService_cancelComponents(t *testing.T) {
+	// Upstream is hanging until download is aborted
+	downloadStarted := make(chan struct{})
+	downloadAborted := make(chan struct{})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(downloadStarted)
+		<-r.Context().Done()
+		close(downloadAborted)
+	}))
+	defer upstream.Close()
+
+	cfg := &config.Server{Concurrency: 1}
+	u := newWebService(cfg, newJobRegistry(time.Hour), &memoryJobStore{}, nil, nil)
+	job, err := u.createJob(&core.NexusExportComponents{
+		NexusServer: core.NexusServer{Host: "http://127.0.0.1:1"},
+		Items: []*core.NexusExportComponent{
+			{
+				Name:            "pkg",
+				Version:         "1.0.0",
+				Format:          "npm",
+				ArtifactsSource: upstream.URL + "/",
+				Assets: []*core.NexusExportComponentAsset{
+					{Name: "pkg", Version: "1.0.0", FileName: "pkg-1.0.0.tgz", Path: "pkg/-/pkg-1.0.0.tgz"},
+				},
+			},
+		},
+	}, "repo1", "user1")
+	if err != nil {
+		t.Fatalf("createJob() error = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		u.runJob(job)
+		close(done)
+	}()
+	<-downloadStarted
+
+	target := config.URIBase + config.URIComponents + "?uuid=" + job.Message.ID.String()
+	// Job of another user can't be canceled or answered
+	w := httptest.NewRecorder()
+	u.cancelComponents(w, userRequest("DELETE", "user2", target))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("cancelComponents() by another user status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	w = httptest.NewRecorder()
+	u.answerMessage(w, userRequest("GET", "user2", target))
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("answerMessage() by another user status = %d, want %d", w.Code, http.StatusForbidden)
+	}
+	if msg, err := u.searchById(job.Message.ID); err != nil || msg.State != JobRunning {
+		t.Fatalf("searchById() = %+v, error = %v, want running job", msg, err)
+	}
+
+	req := userRequest("DELETE", "user1", target)
+	w = httptest.NewRecorder()
+	u.cancelComponents(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("cancelComponents() status = %d, body = %s", w.Code, w.Body.String())
+	}
+
+	select {
+	case <-downloadAborted:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("download was not aborted")
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("job was not finished")
+	}
+
+	msg, err := u.searchById(job.Message.ID)
+	if err != nil {
+		t.Fatalf("searchById() error = %v", err)
+	}
+	if msg.State != JobCancelled || !msg.Complete {
+		t.Errorf("searchById() = %+v, want canceled job", msg)
+	}
+
+	// Finished job can't be canceled twice
+	w = httptest.NewRecorder()
+	u.cancelComponents(w, req)
+	if w.Code == http.StatusOK {
+		t.Errorf("cancelComponents() of finished job must fail")
+	}
+	var body string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Errorf("cancelComponents() error body = %s", w.Body.String())
+	}
+}
+
+// userRequest returns request authenticated by user
+func userRequest(method string, user string, target string) *http.Request {
+	req := httptest.NewRequest(method, target, nil)
+	return req.WithContext(context.WithValue(req.Context(), userContextKey{}, user))
+}
+
+func Test_webService_jobEvents(t *testing.T) {
+	u := newWebService(&config.Server{}, newJobRegistry(time.Hour), &memoryJobStore{}, nil, nil)
+	job := &Job{Message: &Message{ID: uuid.New()}, Components: &core.NexusExportComponents{}}
+	u.jobs.add(job)
+	id := job.Message.ID
+	if _, err := u.jobs.start(id); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	// Event received by client before reconnect
+	u.jobs.saveResult(id, "path/file1.tgz", "")
+
+	router := mux.NewRouter()
+	router.HandleFunc(config.URIBase+config.URIJobs+"/{id}"+config.URIEvents, u.jobEvents)
+	srv := httptest.NewServer(router)
+	defer srv.Close()
+
+	req, err := http.NewRequest("GET", srv.URL+config.URIBase+config.URIJobs+"/"+id.String()+config.URIEvents, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Last-Event-ID", "1")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("jobEvents() error = %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("jobEvents() status = %d, content type = %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	// Events published while client is connected
+	u.jobs.saveResult(id, "path/file2.tgz", "some error")
+	if err := u.completeById(id, JobFailed, []string{"some error"}); err != nil {
+		t.Fatalf("completeById() error = %v", err)
+	}
+
+	// Stream is closed after complete event
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatalf("jobEvents() read error = %v", err)
+	}
+	got := string(body)
+	if strings.Contains(got, "path/file1.tgz") {
+		t.Errorf("jobEvents() sent event before Last-Event-ID: %s", got)
+	}
+	for _, want := range []string{
+		"id: 2\nevent: result\ndata: {\"path\":\"path/file2.tgz\",\"error\":\"some error\"",
+		"id: 3\nevent: complete\ndata: {\"id\":\"" + id.String() + "\",\"state\":\"failed\"",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("jobEvents() = %s, want %s", got, want)
+		}
+	}
+	// Complete job is kept in history
+	if _, err := u.searchById(id); err != nil {
+		t.Errorf("searchById() error = %v", err)
+	}
+}
+
+func Test_webService_listJobs(t *testing.T) {
+	u := newWebService(&config.Server{Authorization: map[string]config.UserPermissions{"admin": {Admin: true}}},
+		newJobRegistry(time.Hour), &memoryJobStore{}, newHMACKeySet([]byte("key")), nil)
+	var job *Job
+	for _, user := range []string{"user1", "user2"} {
+		var err error
+		job, err = u.createJob(&core.NexusExportComponents{NexusServer: core.NexusServer{Host: "https://nexus.some"}},
+			"repo1", user)
+		if err != nil {
+			t.Fatalf("createJob() error = %v", err)
+		}
+	}
+	tests := []struct {
+		name     string
+		user     string
+		query    string
+		wantCode int
+		wantLen  int
+	}{
+		{"own jobs", "user1", "", http.StatusOK, 1},
+		{"admin sees all jobs", "admin", "", http.StatusOK, 2},
+		{"matching filters", "user1", "?state=queued&host=https://nexus.some&repository=repo1&user=user1", http.StatusOK, 1},
+		{"not matching filter", "admin", "?user=user3", http.StatusOK, 0},
+		{"jobs of other user", "user1", "?user=user2", http.StatusForbidden, 0},
+		{"unknown state", "user1", "?state=unknown", http.StatusUnprocessableEntity, 0},
+		{"wrong since", "user1", "?since=yesterday", http.StatusUnprocessableEntity, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			u.listJobs(w, userRequest("GET", tt.user, config.URIBase+config.URIJobs+tt.query))
+			if w.Code != tt.wantCode {
+				t.Fatalf("listJobs() status = %d, want %d", w.Code, tt.wantCode)
+			}
+			if tt.wantCode != http.StatusOK {
+				return
+			}
+			var got []*JobRecord
+			if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+				t.Fatalf("listJobs() body = %s", w.Body.String())
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("listJobs() got %d records, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+
+	// Full record is requested by job id, it's available for job owner and admin only
+	for _, user := range []string{"user2", "admin", "user1"} {
+		req := mux.SetURLVars(userRequest("GET", user, config.URIBase+config.URIJobs+"/"+job.Message.ID.String()),
+			map[string]string{"id": job.Message.ID.String()})
+		w := httptest.NewRecorder()
+		u.jobRecord(w, req)
+		if user == "user1" {
+			if w.Code != http.StatusForbidden {
+				t.Errorf("jobRecord() status = %d, want %d for user1", w.Code, http.StatusForbidden)
+			}
+			continue
+		}
+		var rec JobRecord
+		if err := json.Unmarshal(w.Body.Bytes(), &rec); err != nil || rec.ID != job.Message.ID || rec.User != "user2" {
+			t.Errorf("jobRecord() status = %d, body = %s", w.Code, w.Body.String())
+		}
+	}
+}
+
+func Test_webService_authMiddle_user(t *testing.T) {
+	u := newWebService(&config.Server{Credentials: map[string]string{"user1": "pass"}},
+		newJobRegistry(time.Hour), &memoryJobStore{}, newHMACKeySet([]byte("key")), nil)
+	// Sign in to get JWT cookie
+	req := httptest.NewRequest("GET", config.URIBase+config.URILogin, nil)
+	req.SetBasicAuth("user1", "pass")
+	w := httptest.NewRecorder()
+	u.signInMiddle(http.HandlerFunc(stub)).ServeHTTP(w, req)
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("signInMiddle() cookies = %v", cookies)
+	}
+
+	var got string
+	req = httptest.NewRequest("GET", config.URIBase+config.URIJobs, nil)
+	req.AddCookie(cookies[0])
+	u.authMiddle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = requestUser(r)
+	})).ServeHTTP(httptest.NewRecorder(), req)
+	if got != "user1" {
+		t.Errorf("requestUser() = %s, want user1", got)
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"nexus-pusher/internal/core"
+	"time"
+)
+
+// createJob will generate new message for upload request of user and save it as a job
+func (u *webService) createJob(nec *core.NexusExportComponents, repo string, user string) (*Job, error) {
+	msg, err := u.genMessageWithId()
+	if err != nil {
+		return nil, fmt.Errorf("createJob: %w", err)
+	}
+	job := &Job{
+		Message:    msg,
+		Repository: repo,
+		Components: nec,
+		User:       user,
+		Host:       nec.NexusServer.Host,
+	}
+	u.jobs.add(job)
+	if err := u.store.Create(job); err != nil {
+		u.deleteById(msg.ID)
+		return nil, fmt.Errorf("createJob: %w", err)
+	}
+	return job, nil
+}
+
+// runJob will upload job components and complete job message with results
+func (u *webService) runJob(job *Job) {
+	id := job.Message.ID
+	ctx, err := u.jobs.start(id)
+	if err != nil {
+		log.Errorf("%v", err)
+		return
+	}
+
+	nec := job.Components
+	// Destination credentials are taken from server profile at run time, so they are never stored
+	s, err := resolveDestination(u.cfg, nec.NexusServer)
+	if err != nil {
+		log.Errorf("%v", err)
+		if err := u.completeById(id, JobFailed, []string{err.Error()}); err != nil {
+			log.Errorf("%v", err)
+		}
+		return
+	}
+	// Artifacts source credentials sent by client take precedence over configured ones
+	s.Upstreams = append(s.Upstreams, nec.Upstreams...)
+	s.Sources = u.sources
+	results := s.UploadComponents(ctx, nec, job.Repository, u.cfg, &jobTracker{id: id, jobs: u.jobs, store: u.store})
+
+	var errorsCounter int
+	var errorsText []string
+	for _, v := range results {
+		if v.Err != nil {
+			errorsCounter++
+			errorsText = append(errorsText,
+				fmt.Sprintf("Asset processing error: %s asset=%s", v.Err.Error(), v.ComponentPath))
+		}
+	}
+	if ctx.Err() != nil {
+		log.Warnf("Upload request %v canceled with %d errors.", id, errorsCounter)
+		// Set complete flag to current client request
+		if err := u.completeById(id, JobCancelled, errorsText); err != nil {
+			log.Errorf("%v", err)
+		}
+	} else if errorsCounter != 0 {
+		log.Warnf("Upload request complete with %d errors:", errorsCounter)
+		for _, v := range errorsText {
+			log.Warnln(v)
+		}
+		// Set complete flag to current client request
+		if err := u.completeById(id, JobFailed, errorsText); err != nil {
+			log.Errorf("%v", err)
+		}
+	} else {
+		log.Printf("Upload request successfully complete.")
+		// Set complete flag to current client request
+		if err := u.completeById(id, JobDone, nil); err != nil {
+			log.Errorf("%v", err)
+		}
+	}
+}
+
+// resumeJobs will restore stored jobs and continue unfinished ones
+func (u *webService) resumeJobs() error {
+	jobs, err := u.store.Load()
+	if err != nil {
+		return fmt.Errorf("resumeJobs: %w", err)
+	}
+	for _, v := range jobs {
+		u.jobs.add(v)
+		if v.Message.State.Finished() {
+			continue
+		}
+		// Credentials sent by client are not stored, so they must be taken from server config
+		if err := restoreCredentials(u.cfg, v.Components); err != nil {
+			log.Errorf("Unable to resume upload request %v: %v", v.Message.ID, err)
+			if err := u.completeById(v.Message.ID, JobFailed, []string{err.Error()}); err != nil {
+				log.Errorf("%v", err)
+			}
+			continue
+		}
+		log.Printf("Resuming upload request %v to '%s' repo at server %s (%d components already processed)",
+			v.Message.ID, v.Repository, v.Components.NexusServer.Host, len(v.Results))
+		go u.runJob(v)
+	}
+	return nil
+}
+
+// evictJobs will periodically remove finished jobs which are older than registry ttl
+func (u *webService) evictJobs(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, id := range u.jobs.evict(now) {
+			if err := u.store.Delete(id); err != nil {
+				log.Errorf("evictJobs: %v", err)
+			}
+		}
+	}
+}
+
+// jobTracker is used to save job upload progress to the registry and job store
+type jobTracker struct {
+	id    uuid.UUID
+	jobs  *jobRegistry
+	store JobStore
+}
+
+// IsUploaded reports whether component was successfully uploaded before
+func (t *jobTracker) IsUploaded(path string) bool {
+	return t.jobs.isUploaded(t.id, path)
+}
+
+func (t *jobTracker) Started(path string) {
+	t.jobs.uploadStarted(t.id, path)
+}
+
+func (t *jobTracker) Transferred(n int64) {
+	t.jobs.transferred(t.id, n)
+}
+
+func (t *jobTracker) Uploaded(result core.UploadResult) {
+	var errText string
+	if result.Err != nil {
+		errText = result.Err.Error()
+	}
+	t.jobs.saveResult(t.id, result.ComponentPath, errText)
+	if err := t.store.SaveResult(t.id, result.ComponentPath, errText); err != nil {
+		log.Errorf("unable to save upload progress: %v", err)
+	}
+}
@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/golang-jwt/jwt"
+	"io/ioutil"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/pkg/utils"
+)
+
+// Minimal length of HMAC secret in bytes
+const minHMACKeySize = 32
+
+// jwtKey is a single key used to sign or verify JWT tokens
+type jwtKey struct {
+	id     string
+	method jwt.SigningMethod
+	// sign is nil for keys which are used to verify tokens only
+	sign   interface{}
+	verify interface{}
+}
+
+// jwtKeySet is holding active JWT keys by id. New tokens are signed with signing key
+type jwtKeySet struct {
+	signing *jwtKey
+	keys    map[string]*jwtKey
+}
+
+// newHMACKeySet returns key set with single HMAC key without id
+func newHMACKeySet(secret []byte) *jwtKeySet {
+	key := &jwtKey{method: jwt.SigningMethodHS256, sign: secret, verify: secret}
+	return &jwtKeySet{signing: key, keys: map[string]*jwtKey{"": key}}
+}
+
+// loadJWTKeys will load JWT keys following server config.
+// Random HMAC key is generated if there are no keys configured
+func loadJWTKeys(cfg *config.Server) (*jwtKeySet, error) {
+	if len(cfg.JWT.Keys) == 0 {
+		return newHMACKeySet(genRandomJWTKey(minHMACKeySize)), nil
+	}
+	ks := &jwtKeySet{keys: make(map[string]*jwtKey)}
+	for _, v := range cfg.JWT.Keys {
+		key, err := loadJWTKey(v)
+		if err != nil {
+			return nil, fmt.Errorf("loadJWTKeys: %w", err)
+		}
+		ks.keys[key.id] = key
+	}
+	signing, ok := ks.keys[cfg.JWT.SigningKey]
+	if !ok {
+		return nil, &utils.ContextError{
+			Context: "loadJWTKeys",
+			Err:     fmt.Errorf("signing key '%s' is not found", cfg.JWT.SigningKey),
+		}
+	}
+	if signing.sign == nil {
+		return nil, &utils.ContextError{
+			Context: "loadJWTKeys",
+			Err:     fmt.Errorf("signing key '%s' must be a private key", signing.id),
+		}
+	}
+	ks.signing = signing
+	return ks, nil
+}
+
+// loadJWTKey will read HMAC secret or PEM encoded key from file.
+// Public key is enough for RSA and ECDSA keys which are used to verify tokens only
+func loadJWTKey(cfg config.JWTKey) (*jwtKey, error) {
+	data, err := ioutil.ReadFile(cfg.Path)
+	if err != nil {
+		return nil, fmt.Errorf("loadJWTKey: %w", err)
+	}
+	key := &jwtKey{id: cfg.ID, method: jwt.GetSigningMethod(cfg.Algorithm)}
+	switch cfg.Algorithm {
+	case jwt.SigningMethodHS256.Alg():
+		secret := bytes.TrimSpace(data)
+		if len(secret) < minHMACKeySize {
+			return nil, &utils.ContextError{
+				Context: "loadJWTKey",
+				Err:     fmt.Errorf("HMAC key '%s' must be at least %d bytes long", cfg.ID, minHMACKeySize),
+			}
+		}
+		key.sign, key.verify = secret, secret
+	case jwt.SigningMethodRS256.Alg():
+		if private, err := jwt.ParseRSAPrivateKeyFromPEM(data); err == nil {
+			key.sign, key.verify = private, &private.PublicKey
+		} else if key.verify, err = jwt.ParseRSAPublicKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("loadJWTKey: key '%s': %w", cfg.ID, err)
+		}
+	case jwt.SigningMethodES256.Alg():
+		if private, err := jwt.ParseECPrivateKeyFromPEM(data); err == nil {
+			key.sign, key.verify = private, &private.PublicKey
+		} else if key.verify, err = jwt.ParseECPublicKeyFromPEM(data); err != nil {
+			return nil, fmt.Errorf("loadJWTKey: key '%s': %w", cfg.ID, err)
+		}
+	default:
+		return nil, &utils.ContextError{
+			Context: "loadJWTKey",
+			Err:     fmt.Errorf("unsupported algorithm '%s' of key '%s'", cfg.Algorithm, cfg.ID),
+		}
+	}
+	return key, nil
+}
+
+// sign will create JWT token signed with signing key
+func (ks *jwtKeySet) sign(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(ks.signing.method, claims)
+	if ks.signing.id != "" {
+		token.Header["kid"] = ks.signing.id
+	}
+	s, err := token.SignedString(ks.signing.sign)
+	if err != nil {
+		return "", fmt.Errorf("sign: %w", err)
+	}
+	return s, nil
+}
+
+// keyFunc returns key to verify token following its key id and algorithm
+func (ks *jwtKeySet) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+	key, ok := ks.keys[kid]
+	if !ok {
+		return nil, &utils.ContextError{
+			Context: "keyFunc",
+			Err:     fmt.Errorf("unknown key id '%s'", kid),
+		}
+	}
+	// Don't allow to verify token with algorithm of another key type
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, &utils.ContextError{
+			Context: "keyFunc",
+			Err:     fmt.Errorf("unexpected signing method '%s' for key '%s'", token.Method.Alg(), kid),
+		}
+	}
+	return key.verify, nil
+}
@@ -0,0 +1,155 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"github.com/golang-jwt/jwt"
+	"io/ioutil"
+	"nexus-pusher/internal/config"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeJWTKeys will generate keys of all supported algorithms and return their configs by id
+func writeJWTKeys(t *testing.T) map[string]config.JWTKey {
+	dir := t.TempDir()
+	write := func(name string, data []byte) string {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, data, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rsaPubDER, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return map[string]config.JWTKey{
+		"hmac": {ID: "hmac", Algorithm: "HS256",
+			Path: write("hmac", []byte(strings.Repeat("s", minHMACKeySize)+"\n"))},
+		"short-hmac": {ID: "short-hmac", Algorithm: "HS256", Path: write("short-hmac", []byte("secret"))},
+		"rsa": {ID: "rsa", Algorithm: "RS256",
+			Path: write("rsa.pem", pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)}))},
+		"rsa-public": {ID: "rsa-public", Algorithm: "RS256",
+			Path: write("rsa-public.pem", pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: rsaPubDER}))},
+		"ec": {ID: "ec", Algorithm: "ES256",
+			Path: write("ec.pem", pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}))},
+	}
+}
+
+func Test_loadJWTKeys(t *testing.T) {
+	keys := writeJWTKeys(t)
+	tests := []struct {
+		name       string
+		signingKey string
+		keys       []string
+		wantErr    bool
+	}{
+		{"hmac", "hmac", []string{"hmac"}, false},
+		{"rsa", "rsa", []string{"rsa", "hmac"}, false},
+		{"ec", "ec", []string{"ec", "rsa-public"}, false},
+		{"short hmac", "short-hmac", []string{"short-hmac"}, true},
+		{"public signing key", "rsa-public", []string{"rsa-public"}, true},
+		{"unknown signing key", "ec", []string{"rsa"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Server{}
+			cfg.JWT.SigningKey = tt.signingKey
+			for _, v := range tt.keys {
+				cfg.JWT.Keys = append(cfg.JWT.Keys, keys[v])
+			}
+			ks, err := loadJWTKeys(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("loadJWTKeys() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			// Signed token must be verified by the same key set
+			s, err := ks.sign(&Claims{Username: "user1"})
+			if err != nil {
+				t.Fatalf("sign() error = %v", err)
+			}
+			claims := &Claims{}
+			if _, err := jwt.ParseWithClaims(s, claims, ks.keyFunc); err != nil || claims.Username != "user1" {
+				t.Errorf("keyFunc() token verification error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_jwtKeySet_rotation(t *testing.T) {
+	keys := writeJWTKeys(t)
+	load := func(signingKey string, ids ...string) *jwtKeySet {
+		cfg := &config.Server{}
+		cfg.JWT.SigningKey = signingKey
+		for _, v := range ids {
+			cfg.JWT.Keys = append(cfg.JWT.Keys, keys[v])
+		}
+		ks, err := loadJWTKeys(cfg)
+		if err != nil {
+			t.Fatalf("loadJWTKeys() error = %v", err)
+		}
+		return ks
+	}
+	claims := &Claims{
+		Username:       "user1",
+		StandardClaims: jwt.StandardClaims{ExpiresAt: time.Now().Add(time.Minute).Unix()},
+	}
+	verify := func(ks *jwtKeySet, s string) error {
+		_, err := jwt.ParseWithClaims(s, &Claims{}, ks.keyFunc)
+		return err
+	}
+
+	oldToken, err := load("hmac", "hmac").sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// New signing key is added while old one is still active
+	rotated := load("ec", "ec", "hmac")
+	if err := verify(rotated, oldToken); err != nil {
+		t.Errorf("token of old key must be valid while key is active: %v", err)
+	}
+	newToken, err := rotated.sign(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Old key is removed
+	if err := verify(load("ec", "ec"), oldToken); err == nil {
+		t.Errorf("token of removed key must be invalid")
+	}
+	if err := verify(load("ec", "ec"), newToken); err != nil {
+		t.Errorf("token of signing key must be valid: %v", err)
+	}
+
+	// Token signed with HMAC using public key of RSA key id must be rejected
+	ks := load("rsa", "rsa")
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	token.Header["kid"] = "rsa"
+	forged, err := token.SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := verify(ks, forged); err == nil {
+		t.Errorf("token with algorithm of another key type must be invalid")
+	}
+}
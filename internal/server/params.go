@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
+	"time"
 )
 
 type Routes struct {
@@ -20,19 +21,82 @@ type Route struct {
 
 type Message struct {
 	ID       uuid.UUID `json:"id"`
+	State    JobState  `json:"state"`
 	Response []string  `json:"response"`
 	Complete bool      `json:"complete"`
+	Progress Progress  `json:"progress"`
+}
+
+// Progress is defines upload progress of job components (or assets).
+// Bundled components are counted once in all counters, the same way as they are uploaded
+type Progress struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	InFlight  int `json:"inFlight"`
+	// Bytes is count of bytes downloaded from upstream
+	Bytes int64 `json:"bytes"`
+	// Current is a list of currently processing components
+	Current []string `json:"current"`
+	// ETASeconds is estimated time to finish job (0 if unknown)
+	ETASeconds int64 `json:"etaSeconds"`
+}
+
+const (
+	// EventResult is a job event sent on single component upload
+	EventResult = "result"
+	// EventComplete is a final job event holding job message
+	EventComplete = "complete"
+)
+
+// ResultEvent is data of job event sent on single component upload
+type ResultEvent struct {
+	Path     string   `json:"path"`
+	Error    string   `json:"error,omitempty"`
+	Progress Progress `json:"progress"`
+}
+
+// JobRecord is defines upload job history record
+type JobRecord struct {
+	ID         uuid.UUID  `json:"id"`
+	State      JobState   `json:"state"`
+	User       string     `json:"user"`
+	Host       string     `json:"host"`
+	Repository string     `json:"repository"`
+	Created    time.Time  `json:"created"`
+	Started    *time.Time `json:"started,omitempty"`
+	Finished   *time.Time `json:"finished,omitempty"`
+	Progress   Progress   `json:"progress"`
+	// Response is holding job errors summary (full record only)
+	Response []string `json:"response,omitempty"`
+	// Errors is holding upload errors by component path (full record only)
+	Errors map[string]string `json:"errors,omitempty"`
 }
 
 type webService struct {
-	cfg      *config.Server
-	messages map[uuid.UUID]*Message
-	jwtKey   []byte
-	ver      *core.Version
+	cfg         *config.Server
+	jobs        *jobRegistry
+	store       JobStore
+	credentials *credentialStore
+	logins      *loginLimiter
+	jwtKeys     *jwtKeySet
+	ver         *core.Version
+	// sources is remembering artifacts sources which served packages between jobs
+	sources *core.SourceHistory
 }
 
-func newWebService(cfg *config.Server, messages map[uuid.UUID]*Message, jwtKey []byte, v *core.Version) *webService {
-	return &webService{cfg: cfg, messages: messages, jwtKey: jwtKey, ver: v}
+func newWebService(cfg *config.Server, jobs *jobRegistry, store JobStore,
+	jwtKeys *jwtKeySet, v *core.Version) *webService {
+	return &webService{
+		cfg:         cfg,
+		jobs:        jobs,
+		store:       store,
+		credentials: newCredentialStore(cfg.Credentials, cfg.Auth.HtpasswdPath),
+		logins:      newLoginLimiter(cfg.Auth.MaxFailedLogins, time.Duration(cfg.Auth.LockoutMinutes)*time.Minute),
+		jwtKeys:     jwtKeys,
+		ver:         v,
+		sources:     core.NewSourceHistory(),
+	}
 }
 
 const (
@@ -0,0 +1,439 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"nexus-pusher/internal/core"
+	"nexus-pusher/pkg/utils"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JobState is defines upload job lifecycle state
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobDone      JobState = "done"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+)
+
+// Finished reports whether job with such state will not change anymore
+func (s JobState) Finished() bool {
+	return s == JobDone || s == JobFailed || s == JobCancelled
+}
+
+// Valid reports whether job state is known
+func (s JobState) Valid() bool {
+	return s == JobQueued || s == JobRunning || s.Finished()
+}
+
+// setState will update job state and set complete flag for finished job
+func (j *Job) setState(state JobState) {
+	j.Message.State = state
+	if state.Finished() {
+		j.Message.Complete = true
+		j.Finished = time.Now()
+		j.inFlight = nil
+		// Release job context
+		if j.cancel != nil {
+			j.cancel()
+			j.cancel = nil
+		}
+		j.publish(EventComplete, j.message(j.Finished))
+	}
+}
+
+// countProgress will reset job progress to count of upload paths and their saved results.
+// Bundled components (i.e. raw assets of one directory) are counted once, the same way
+// as they are uploaded, so results are counted in the same units as total
+func (j *Job) countProgress() {
+	j.Message.Progress = Progress{}
+	for _, v := range core.UploadPaths(j.Components) {
+		j.Message.Progress.Total++
+		errText, ok := j.Results[v]
+		if !ok {
+			continue
+		}
+		if errText == "" {
+			j.Message.Progress.Succeeded++
+		} else if j.Message.State.Finished() {
+			j.Message.Progress.Failed++
+		}
+	}
+}
+
+// message returns copy of job message with current progress
+func (j *Job) message(now time.Time) *Message {
+	msg := *j.Message
+	msg.Response = append([]string(nil), j.Message.Response...)
+	msg.Progress = j.progress(now)
+	return &msg
+}
+
+// record returns job history record. Upload errors are added to full record only.
+// Must be called with registry lock held
+func (j *Job) record(now time.Time, full bool) *JobRecord {
+	rec := &JobRecord{
+		ID:         j.Message.ID,
+		State:      j.Message.State,
+		User:       j.User,
+		Host:       j.Host,
+		Repository: j.Repository,
+		Created:    j.Created,
+		Progress:   j.progress(now),
+	}
+	if !j.started.IsZero() {
+		started := j.started
+		rec.Started = &started
+	}
+	if !j.Finished.IsZero() {
+		finished := j.Finished
+		rec.Finished = &finished
+	}
+	if full {
+		rec.Response = append([]string(nil), j.Message.Response...)
+		for k, v := range j.Results {
+			if v == "" {
+				continue
+			}
+			if rec.Errors == nil {
+				rec.Errors = make(map[string]string)
+			}
+			rec.Errors[k] = v
+		}
+	}
+	return rec
+}
+
+// jobFilter is defines job history filters, empty fields are not filtered
+type jobFilter struct {
+	State      JobState
+	Host       string
+	Repository string
+	User       string
+	// Since is filtering jobs created at or after this time
+	Since time.Time
+}
+
+func (f jobFilter) match(j *Job) bool {
+	return (f.State == "" || j.Message.State == f.State) &&
+		(f.Host == "" || strings.TrimRight(j.Host, "/") == strings.TrimRight(f.Host, "/")) &&
+		(f.Repository == "" || j.Repository == f.Repository) &&
+		(f.User == "" || j.User == f.User) &&
+		(f.Since.IsZero() || !j.Created.Before(f.Since))
+}
+
+// jobEvent is a single event of job events stream
+type jobEvent struct {
+	id   int
+	name string
+	data []byte
+}
+
+// publish will append event to job events history and wake up its subscribers
+func (j *Job) publish(name string, data interface{}) {
+	b, err := json.Marshal(data)
+	if err != nil {
+		log.Errorf("publish: unable to encode %s event of job %v: %v", name, j.Message.ID, err)
+		return
+	}
+	j.events = append(j.events, jobEvent{id: len(j.events) + 1, name: name, data: b})
+	if j.changed != nil {
+		close(j.changed)
+	}
+	j.changed = make(chan struct{})
+}
+
+// progress returns copy of job progress with currently processing components and ETA.
+// Must be called with registry lock held
+func (j *Job) progress(now time.Time) Progress {
+	p := j.Message.Progress
+	p.Current = nil
+	p.InFlight = len(j.inFlight)
+	p.ETASeconds = 0
+	if j.Message.State != JobRunning {
+		return p
+	}
+	for k := range j.inFlight {
+		p.Current = append(p.Current, k)
+	}
+	sort.Strings(p.Current)
+	// Estimate remaining time following average time of components processed since job start
+	if remaining := p.Total - p.Succeeded - p.Failed; j.processed > 0 && remaining > 0 {
+		perComponent := now.Sub(j.started) / time.Duration(j.processed)
+		p.ETASeconds = int64((perComponent * time.Duration(remaining)).Seconds())
+	}
+	return p
+}
+
+// jobRegistry is keeping server jobs safe for concurrent access
+type jobRegistry struct {
+	mu   sync.RWMutex
+	jobs map[uuid.UUID]*Job
+	// Finished jobs are evicted after ttl
+	ttl time.Duration
+}
+
+func newJobRegistry(ttl time.Duration) *jobRegistry {
+	return &jobRegistry{jobs: make(map[uuid.UUID]*Job), ttl: ttl}
+}
+
+// add will register job. New jobs are marked as queued
+func (r *jobRegistry) add(job *Job) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job.Message.State == "" {
+		job.Message.State = JobQueued
+	}
+	if job.Created.IsZero() {
+		job.Created = time.Now()
+	}
+	if job.Results == nil {
+		job.Results = make(map[string]string)
+	}
+	// Jobs stored before host was recorded
+	if job.Host == "" && job.Components != nil {
+		job.Host = job.Components.NexusServer.Host
+	}
+	// Count job components and results of job restored from store
+	if job.Components != nil && job.Message.Progress.Total == 0 {
+		job.countProgress()
+	}
+	job.changed = make(chan struct{})
+	// Replay results of job restored from store to its events stream
+	if len(job.events) == 0 {
+		paths := make([]string, 0, len(job.Results))
+		for k := range job.Results {
+			paths = append(paths, k)
+		}
+		sort.Strings(paths)
+		for _, v := range paths {
+			job.publish(EventResult, &ResultEvent{Path: v, Error: job.Results[v]})
+		}
+		if job.Message.State.Finished() {
+			job.publish(EventComplete, job.message(job.Finished))
+		}
+	}
+	r.jobs[job.Message.ID] = job
+}
+
+// message returns copy of job message to be safely sent to client
+func (r *jobRegistry) message(id uuid.UUID) (*Message, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, &utils.ContextError{
+			Context: "message",
+			Err:     fmt.Errorf("id %v not found", id),
+		}
+	}
+	return job.message(time.Now()), nil
+}
+
+// events returns job events published after specified event id and a channel
+// which is closed when new event is published
+func (r *jobRegistry) events(id uuid.UUID, after int) ([]jobEvent, <-chan struct{}, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, nil, &utils.ContextError{
+			Context: "events",
+			Err:     fmt.Errorf("id %v not found", id),
+		}
+	}
+	// Event ids are not known to server after restart, so whole stream is sent again
+	if after < 0 || after > len(job.events) {
+		after = 0
+	}
+	return append([]jobEvent(nil), job.events[after:]...), job.changed, nil
+}
+
+// list returns history records of jobs matching filter, newest jobs go first
+func (r *jobRegistry) list(filter jobFilter) []*JobRecord {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	now := time.Now()
+	records := make([]*JobRecord, 0)
+	for _, v := range r.jobs {
+		if filter.match(v) {
+			records = append(records, v.record(now, false))
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Created.After(records[j].Created)
+	})
+	return records
+}
+
+// record returns full history record of job
+func (r *jobRegistry) record(id uuid.UUID) (*JobRecord, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, &utils.ContextError{
+			Context: "record",
+			Err:     fmt.Errorf("id %v not found", id),
+		}
+	}
+	return job.record(time.Now(), true), nil
+}
+
+// setState will change state of unfinished job
+func (r *jobRegistry) setState(id uuid.UUID, state JobState) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := r.unfinishedJob(id)
+	if err != nil {
+		return fmt.Errorf("setState: %w", err)
+	}
+	job.setState(state)
+	return nil
+}
+
+// start will mark job as running and return job context which is canceled by job cancel
+func (r *jobRegistry) start(id uuid.UUID) (context.Context, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := r.unfinishedJob(id)
+	if err != nil {
+		return nil, fmt.Errorf("start: %w", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	job.cancel = cancel
+	job.setState(JobRunning)
+
+	// Components uploaded before server restart are skipped
+	job.countProgress()
+	job.started = time.Now()
+	job.processed = 0
+	job.inFlight = make(map[string]struct{})
+	return ctx, nil
+}
+
+// cancel will abort running job, which is finished later by its runner.
+// Job which is not started yet is finished right away and true is returned
+func (r *jobRegistry) cancel(id uuid.UUID) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := r.unfinishedJob(id)
+	if err != nil {
+		return false, fmt.Errorf("cancel: %w", err)
+	}
+	if job.cancel == nil {
+		job.setState(JobCancelled)
+		return true, nil
+	}
+	job.cancel()
+	return false, nil
+}
+
+// complete will finish job with state and response
+func (r *jobRegistry) complete(id uuid.UUID, state JobState, response []string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, err := r.unfinishedJob(id)
+	if err != nil {
+		return fmt.Errorf("complete: %w", err)
+	}
+	job.Message.Response = response
+	job.setState(state)
+	return nil
+}
+
+// unfinishedJob must be called with registry lock held
+func (r *jobRegistry) unfinishedJob(id uuid.UUID) (*Job, error) {
+	job, ok := r.jobs[id]
+	if !ok {
+		return nil, &utils.ContextError{
+			Context: "unfinishedJob",
+			Err:     fmt.Errorf("id %v not found", id),
+		}
+	}
+	if job.Message.State.Finished() {
+		return nil, &utils.ContextError{
+			Context: "unfinishedJob",
+			Err:     fmt.Errorf("job %v is already %s", id, job.Message.State),
+		}
+	}
+	return job, nil
+}
+
+func (r *jobRegistry) remove(id uuid.UUID) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.jobs, id)
+}
+
+// isUploaded reports whether job component was successfully uploaded before
+func (r *jobRegistry) isUploaded(id uuid.UUID, path string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return false
+	}
+	errText, ok := job.Results[path]
+	return ok && errText == ""
+}
+
+// saveResult will save upload result of job component
+func (r *jobRegistry) saveResult(id uuid.UUID, path string, errText string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	job, ok := r.jobs[id]
+	if !ok {
+		return
+	}
+	job.Results[path] = errText
+	delete(job.inFlight, path)
+	job.processed++
+	if errText == "" {
+		job.Message.Progress.Succeeded++
+	} else {
+		job.Message.Progress.Failed++
+	}
+	job.publish(EventResult, &ResultEvent{Path: path, Error: errText, Progress: job.progress(time.Now())})
+}
+
+// uploadStarted will mark job component as currently processing
+func (r *jobRegistry) uploadStarted(id uuid.UUID, path string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok && job.inFlight != nil {
+		job.inFlight[path] = struct{}{}
+	}
+}
+
+// transferred will add count of downloaded bytes to job progress
+func (r *jobRegistry) transferred(id uuid.UUID, n int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if job, ok := r.jobs[id]; ok {
+		job.Message.Progress.Bytes += n
+	}
+}
+
+// evict will remove jobs finished before ttl and return their ids
+func (r *jobRegistry) evict(now time.Time) []uuid.UUID {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var evicted []uuid.UUID
+	for id, job := range r.jobs {
+		if job.Message.State.Finished() && now.Sub(job.Finished) > r.ttl {
+			delete(r.jobs, id)
+			evicted = append(evicted, id)
+		}
+	}
+	return evicted
+}
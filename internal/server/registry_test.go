@@ -0,0 +1,415 @@
+package server
+
+import (
+	"fmt"
+	"github.com/google/uuid"
+	"nexus-pusher/internal/core"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func Test_jobRegistry_lifecycle(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	job := &Job{Message: &Message{ID: uuid.New()}}
+	r.add(job)
+
+	msg, err := r.message(job.Message.ID)
+	if err != nil {
+		t.Fatalf("message() error = %v", err)
+	}
+	if msg.State != JobQueued || msg.Complete {
+		t.Errorf("message() got state %s complete %v, want queued job", msg.State, msg.Complete)
+	}
+
+	if err := r.setState(job.Message.ID, JobRunning); err != nil {
+		t.Fatalf("setState() error = %v", err)
+	}
+	if err := r.complete(job.Message.ID, JobFailed, []string{"some error"}); err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+	// Finished job state can't be changed
+	if err := r.complete(job.Message.ID, JobDone, nil); err == nil {
+		t.Errorf("complete() of finished job must fail")
+	}
+	if err := r.setState(job.Message.ID, JobCancelled); err == nil {
+		t.Errorf("setState() of finished job must fail")
+	}
+
+	msg, err = r.message(job.Message.ID)
+	if err != nil {
+		t.Fatalf("message() error = %v", err)
+	}
+	if msg.State != JobFailed || !msg.Complete || len(msg.Response) != 1 {
+		t.Errorf("message() = %+v, want failed complete job with response", msg)
+	}
+	// Returned message must be a copy
+	msg.Response[0] = "changed"
+	if msg2, _ := r.message(job.Message.ID); msg2.Response[0] != "some error" {
+		t.Errorf("message() returned shared response slice")
+	}
+
+	if _, err := r.message(uuid.New()); err == nil {
+		t.Errorf("message() of unknown job must fail")
+	}
+}
+
+func Test_jobRegistry_evict(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	running := &Job{Message: &Message{ID: uuid.New()}}
+	finished := &Job{Message: &Message{ID: uuid.New()}}
+	r.add(running)
+	r.add(finished)
+	if err := r.setState(running.Message.ID, JobRunning); err != nil {
+		t.Fatalf("setState() error = %v", err)
+	}
+	if err := r.complete(finished.Message.ID, JobDone, nil); err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+
+	if got := r.evict(time.Now()); len(got) != 0 {
+		t.Errorf("evict() before ttl = %v, want nothing", got)
+	}
+	// Unfinished jobs are never evicted
+	got := r.evict(time.Now().Add(2 * time.Hour))
+	if len(got) != 1 || got[0] != finished.Message.ID {
+		t.Errorf("evict() after ttl = %v, want [%v]", got, finished.Message.ID)
+	}
+	if _, err := r.message(finished.Message.ID); err == nil {
+		t.Errorf("evicted job is still registered")
+	}
+	if _, err := r.message(running.Message.ID); err != nil {
+		t.Errorf("running job was evicted")
+	}
+}
+
+// Test_jobRegistry_concurrent is meant to be run with race detector
+func Test_jobRegistry_concurrent(t *testing.T) {
+	const jobsCount = 50
+	const resultsCount = 20
+	r := newJobRegistry(0)
+
+	var wg sync.WaitGroup
+	ids := make(chan uuid.UUID, jobsCount)
+	for i := 0; i < jobsCount; i++ {
+		wg.Add(1)
+		// Submit and run job
+		go func() {
+			defer wg.Done()
+			job := &Job{Message: &Message{ID: uuid.New()}}
+			r.add(job)
+			ids <- job.Message.ID
+			if err := r.setState(job.Message.ID, JobRunning); err != nil {
+				t.Errorf("setState() error = %v", err)
+				return
+			}
+			for j := 0; j < resultsCount; j++ {
+				path := fmt.Sprintf("path/file%d.tgz", j)
+				if !r.isUploaded(job.Message.ID, path) {
+					r.saveResult(job.Message.ID, path, "")
+				}
+			}
+			if err := r.complete(job.Message.ID, JobDone, nil); err != nil {
+				t.Errorf("complete() error = %v", err)
+			}
+		}()
+	}
+
+	// Poll jobs until they are complete
+	var pollers sync.WaitGroup
+	for i := 0; i < jobsCount; i++ {
+		pollers.Add(1)
+		go func() {
+			defer pollers.Done()
+			id := <-ids
+			for {
+				msg, err := r.message(id)
+				if err != nil {
+					// Job was evicted after completion
+					return
+				}
+				if msg.Complete {
+					return
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+
+	// Evict finished jobs in the meantime
+	stop := make(chan struct{})
+	evicted := make(chan int)
+	go func() {
+		var n int
+		for {
+			select {
+			case <-stop:
+				evicted <- n + len(r.evict(time.Now().Add(time.Second)))
+				return
+			default:
+				n += len(r.evict(time.Now().Add(time.Second)))
+				time.Sleep(time.Millisecond)
+			}
+		}
+	}()
+
+	wg.Wait()
+	pollers.Wait()
+	close(stop)
+	if n := <-evicted; n != jobsCount {
+		t.Errorf("evict() evicted %d jobs, want %d", n, jobsCount)
+	}
+}
+
+func Test_jobRegistry_cancel(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	queued := &Job{Message: &Message{ID: uuid.New()}, Components: &core.NexusExportComponents{}}
+	running := &Job{Message: &Message{ID: uuid.New()}, Components: &core.NexusExportComponents{}}
+	r.add(queued)
+	r.add(running)
+
+	// Queued job is finished right away
+	finished, err := r.cancel(queued.Message.ID)
+	if err != nil || !finished {
+		t.Fatalf("cancel() = %v, %v, want true, nil", finished, err)
+	}
+	if _, err := r.start(queued.Message.ID); err == nil {
+		t.Errorf("start() of canceled job must fail")
+	}
+
+	// Running job is finished by its runner
+	ctx, err := r.start(running.Message.ID)
+	if err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	finished, err = r.cancel(running.Message.ID)
+	if err != nil || finished {
+		t.Fatalf("cancel() = %v, %v, want false, nil", finished, err)
+	}
+	select {
+	case <-ctx.Done():
+	default:
+		t.Fatalf("job context is not canceled")
+	}
+	if msg, _ := r.message(running.Message.ID); msg.State != JobRunning {
+		t.Errorf("message() state = %s, want %s", msg.State, JobRunning)
+	}
+	if err := r.complete(running.Message.ID, JobCancelled, nil); err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+	if _, err := r.cancel(running.Message.ID); err == nil {
+		t.Errorf("cancel() of finished job must fail")
+	}
+}
+
+func Test_jobRegistry_progress(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	job := &Job{
+		Message: &Message{ID: uuid.New()},
+		Components: &core.NexusExportComponents{
+			Items: []*core.NexusExportComponent{
+				{
+					Format: "npm",
+					Assets: []*core.NexusExportComponentAsset{
+						{Path: "path/file1.tgz"}, {Path: "path/file2.tgz"}, {Path: "path/file3.tgz"},
+					},
+				},
+				{
+					Name:    "group.artifact",
+					Version: "1.0",
+					Format:  "maven2",
+					Assets: []*core.NexusExportComponentAsset{
+						{Path: "path/artifact-1.0.jar"}, {Path: "path/artifact-1.0.pom"},
+					},
+				},
+			},
+		},
+		// Uploaded before restart
+		Results: map[string]string{"path/file1.tgz": "", "path/file2.tgz": "some error"},
+	}
+	r.add(job)
+	id := job.Message.ID
+	if _, err := r.start(id); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+
+	r.uploadStarted(id, "path/file2.tgz")
+	r.uploadStarted(id, "group.artifact-1.0")
+	r.transferred(id, 100)
+	r.transferred(id, 50)
+	r.saveResult(id, "path/file2.tgz", "")
+
+	msg, err := r.message(id)
+	if err != nil {
+		t.Fatalf("message() error = %v", err)
+	}
+	got := msg.Progress
+	want := Progress{Total: 4, Succeeded: 2, Failed: 0, InFlight: 1, Bytes: 150, Current: []string{"group.artifact-1.0"}}
+	// ETA depends on time
+	got.ETASeconds = 0
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("message() progress = %+v, want %+v", got, want)
+	}
+
+	r.saveResult(id, "group.artifact-1.0", "some error")
+	r.saveResult(id, "path/file3.tgz", "")
+	if err := r.complete(id, JobFailed, []string{"some error"}); err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+	msg, _ = r.message(id)
+	want = Progress{Total: 4, Succeeded: 3, Failed: 1, Bytes: 150}
+	if !reflect.DeepEqual(msg.Progress, want) {
+		t.Errorf("message() progress = %+v, want %+v", msg.Progress, want)
+	}
+}
+
+func Test_jobRegistry_progressRawBundle(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	var assets []*core.NexusExportComponentAsset
+	for i := 0; i < 25; i++ {
+		assets = append(assets, &core.NexusExportComponentAsset{Path: fmt.Sprintf("dir1/file%d.txt", i)})
+	}
+	assets = append(assets, &core.NexusExportComponentAsset{Path: "dir2/file.txt"})
+	job := &Job{
+		Message:    &Message{ID: uuid.New()},
+		Components: &core.NexusExportComponents{Items: []*core.NexusExportComponent{{Format: "raw", Assets: assets}}},
+		// Asset results are not counted, raw assets of one directory are uploaded as bundle
+		Results: map[string]string{"dir1": "", "dir2": "some error", "dir1/file0.txt": ""},
+	}
+	r.add(job)
+	id := job.Message.ID
+
+	msg, _ := r.message(id)
+	want := Progress{Total: 3, Succeeded: 1}
+	if !reflect.DeepEqual(msg.Progress, want) {
+		t.Errorf("add() progress = %+v, want %+v", msg.Progress, want)
+	}
+
+	if _, err := r.start(id); err != nil {
+		t.Fatalf("start() error = %v", err)
+	}
+	for _, v := range core.UploadPaths(job.Components)[1:] {
+		r.saveResult(id, v, "")
+	}
+	msg, _ = r.message(id)
+	got := msg.Progress
+	got.ETASeconds = 0
+	want = Progress{Total: 3, Succeeded: 3}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("saveResult() progress = %+v, want %+v", got, want)
+	}
+}
+
+func Test_jobRegistry_events(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	// Job restored from store after restart
+	job := &Job{
+		Message: &Message{ID: uuid.New(), State: JobFailed, Complete: true},
+		Results: map[string]string{"path/file2.tgz": "some error", "path/file1.tgz": ""},
+	}
+	r.add(job)
+	id := job.Message.ID
+
+	tests := []struct {
+		name  string
+		after int
+		want  []string
+	}{
+		{"whole stream", 0, []string{EventResult, EventResult, EventComplete}},
+		{"after last event id", 2, []string{EventComplete}},
+		{"unknown event id", 10, []string{EventResult, EventResult, EventComplete}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, _, err := r.events(id, tt.after)
+			if err != nil {
+				t.Fatalf("events() error = %v", err)
+			}
+			var got []string
+			for _, v := range events {
+				got = append(got, v.name)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("events() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	events, _, _ := r.events(id, 0)
+	if want := `{"path":"path/file1.tgz","progress":`; !strings.HasPrefix(string(events[0].data), want) {
+		t.Errorf("events() first event = %s, want prefix %s", events[0].data, want)
+	}
+	if _, _, err := r.events(uuid.New(), 0); err == nil {
+		t.Errorf("events() of unknown job must fail")
+	}
+}
+
+func Test_jobRegistry_list(t *testing.T) {
+	r := newJobRegistry(time.Hour)
+	now := time.Now()
+	newJob := func(user, host, repo string, created time.Time) *Job {
+		job := &Job{
+			Message:    &Message{ID: uuid.New()},
+			Repository: repo,
+			User:       user,
+			Host:       host,
+			Created:    created,
+			Components: &core.NexusExportComponents{},
+		}
+		r.add(job)
+		return job
+	}
+	old := newJob("user1", "https://nexus1.some", "repo1", now.Add(-48*time.Hour))
+	failed := newJob("user1", "https://nexus1.some/", "repo1", now.Add(-24*time.Hour))
+	queued := newJob("user2", "https://nexus2.some", "repo2", now)
+	r.saveResult(failed.Message.ID, "path/file1.tgz", "")
+	r.saveResult(failed.Message.ID, "path/file2.tgz", "some error")
+	if err := r.complete(failed.Message.ID, JobFailed, []string{"some error"}); err != nil {
+		t.Fatalf("complete() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter jobFilter
+		want   []*Job
+	}{
+		{"all jobs newest first", jobFilter{}, []*Job{queued, failed, old}},
+		{"by state", jobFilter{State: JobFailed}, []*Job{failed}},
+		{"by host", jobFilter{Host: "https://nexus1.some"}, []*Job{failed, old}},
+		{"by repository and user", jobFilter{Repository: "repo2", User: "user2"}, []*Job{queued}},
+		{"by created time", jobFilter{Repository: "repo1", Since: now.Add(-25 * time.Hour)}, []*Job{failed}},
+		{"nothing found", jobFilter{User: "user3"}, []*Job{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := r.list(tt.filter)
+			if len(got) != len(tt.want) {
+				t.Fatalf("list() got %d records, want %d", len(got), len(tt.want))
+			}
+			for i, v := range got {
+				if v.ID != tt.want[i].Message.ID {
+					t.Errorf("list() record %d id = %v, want %v", i, v.ID, tt.want[i].Message.ID)
+				}
+				if v.Errors != nil || v.Response != nil {
+					t.Errorf("list() record %d must not hold errors", i)
+				}
+			}
+		})
+	}
+
+	rec, err := r.record(failed.Message.ID)
+	if err != nil {
+		t.Fatalf("record() error = %v", err)
+	}
+	if rec.State != JobFailed || rec.Finished == nil || rec.Progress.Succeeded != 1 || rec.Progress.Failed != 1 {
+		t.Errorf("record() = %+v, want failed job with 1 succeeded and 1 failed asset", rec)
+	}
+	if want := map[string]string{"path/file2.tgz": "some error"}; !reflect.DeepEqual(rec.Errors, want) {
+		t.Errorf("record() errors = %v, want %v", rec.Errors, want)
+	}
+	if _, err := r.record(uuid.New()); err == nil {
+		t.Errorf("record() of unknown job must fail")
+	}
+}
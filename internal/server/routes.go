@@ -1,15 +1,34 @@
 package server
 
 import (
-	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	log "github.com/sirupsen/logrus"
 	"net/http"
 	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
+	"time"
 )
 
 func NewRouter(cfg *config.Server, v *core.Version) *mux.Router {
-	us := newWebService(cfg, make(map[uuid.UUID]*Message), genRandomJWTKey(32), v)
+	store, err := newJobStore(cfg)
+	if err != nil {
+		log.Fatalf("unable to open job store: %v", err)
+	}
+	jobs := newJobRegistry(time.Duration(cfg.Jobs.TTLMinutes) * time.Minute)
+	jwtKeys, err := loadJWTKeys(cfg)
+	if err != nil {
+		log.Fatalf("unable to load JWT keys: %v", err)
+	}
+	us := newWebService(cfg, jobs, store, jwtKeys, v)
+	if err := us.credentials.reload(); err != nil {
+		log.Fatalf("unable to load htpasswd file: %v", err)
+	}
+	// Continue jobs interrupted by server restart
+	if err := us.resumeJobs(); err != nil {
+		log.Fatalf("unable to resume jobs: %v", err)
+	}
+	// Drop finished jobs which results were never requested by client
+	go us.evictJobs(time.Minute)
 	var r = Routes{Routes: []Route{
 		{"login", "GET", config.URIBase + config.URILogin, stub},
 		{"refresh", "GET", config.URIBase + config.URIRefresh, stub},
@@ -17,6 +36,10 @@ func NewRouter(cfg *config.Server, v *core.Version) *mux.Router {
 		{Name: "version", Method: "GET", Pattern: config.URIBase + config.URIVersion, HandlerFunc: us.version},
 		{"post-components", "POST", config.URIBase + config.URIComponents, us.components},
 		{Name: "get-answer", Method: "GET", Pattern: config.URIBase + config.URIComponents, HandlerFunc: us.answerMessage},
+		{Name: "delete-components", Method: "DELETE", Pattern: config.URIBase + config.URIComponents, HandlerFunc: us.cancelComponents},
+		{Name: "get-jobs", Method: "GET", Pattern: config.URIBase + config.URIJobs, HandlerFunc: us.listJobs},
+		{Name: "get-job", Method: "GET", Pattern: config.URIBase + config.URIJobs + "/{id}", HandlerFunc: us.jobRecord},
+		{Name: "get-job-events", Method: "GET", Pattern: config.URIBase + config.URIJobs + "/{id}" + config.URIEvents, HandlerFunc: us.jobEvents},
 	}}
 
 	router := mux.NewRouter().StrictSlash(true)
@@ -0,0 +1,316 @@
+package server
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/goccy/go-json"
+	"github.com/google/uuid"
+	log "github.com/sirupsen/logrus"
+	"io/ioutil"
+	"nexus-pusher/internal/config"
+	"nexus-pusher/internal/core"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job is defines upload job with its payload and progress
+type Job struct {
+	Message    *Message                    `json:"message"`
+	Repository string                      `json:"repository"`
+	Components *core.NexusExportComponents `json:"components"`
+	// User is a name of user submitted the job
+	User string `json:"user"`
+	// Host is a destination nexus server of the job
+	Host string `json:"host"`
+	// Results is holding upload error text by component path (empty for successful upload)
+	Results  map[string]string `json:"results"`
+	Created  time.Time         `json:"created"`
+	Finished time.Time         `json:"finished"`
+	// cancel aborts running job uploads
+	cancel context.CancelFunc
+	// started is a time when job was started (or resumed)
+	started time.Time
+	// processed is a count of components processed since job start
+	processed int
+	// inFlight is holding currently processing components
+	inFlight map[string]struct{}
+	// events is holding job events stream history
+	events []jobEvent
+	// changed is closed and replaced on every new job event
+	changed chan struct{}
+}
+
+// JobStore is used to persist upload jobs between server restarts
+type JobStore interface {
+	// Create saves new job
+	Create(job *Job) error
+	// SaveResult saves upload result of single job component
+	SaveResult(id uuid.UUID, path string, errText string) error
+	// Complete marks job as finished with state and response
+	Complete(id uuid.UUID, state JobState, response []string) error
+	// Delete removes job from store
+	Delete(id uuid.UUID) error
+	// Load returns all stored jobs
+	Load() ([]*Job, error)
+	// Close releases store resources
+	Close() error
+}
+
+// newJobStore will return job store following server config
+func newJobStore(cfg *config.Server) (JobStore, error) {
+	if cfg.Jobs.StorePath == "" {
+		return &memoryJobStore{}, nil
+	}
+	js, err := openJournalJobStore(cfg.Jobs.StorePath)
+	if err != nil {
+		return nil, fmt.Errorf("newJobStore: %w", err)
+	}
+	return js, nil
+}
+
+// memoryJobStore is not persisting anything, jobs live in server memory only
+type memoryJobStore struct{}
+
+func (m *memoryJobStore) Create(*Job) error                            { return nil }
+func (m *memoryJobStore) SaveResult(uuid.UUID, string, string) error   { return nil }
+func (m *memoryJobStore) Complete(uuid.UUID, JobState, []string) error { return nil }
+func (m *memoryJobStore) Delete(uuid.UUID) error                       { return nil }
+func (m *memoryJobStore) Load() ([]*Job, error)                        { return nil, nil }
+func (m *memoryJobStore) Close() error                                 { return nil }
+
+const (
+	journalOpCreate   = "create"
+	journalOpResult   = "result"
+	journalOpComplete = "complete"
+	journalOpDelete   = "delete"
+)
+
+// journalRecord is a single line of jobs journal
+type journalRecord struct {
+	Op       string    `json:"op"`
+	ID       uuid.UUID `json:"id"`
+	Job      *Job      `json:"job,omitempty"`
+	Path     string    `json:"path,omitempty"`
+	Error    string    `json:"error,omitempty"`
+	Response []string  `json:"response,omitempty"`
+	State    JobState  `json:"state,omitempty"`
+	Time     time.Time `json:"time"`
+}
+
+// journalCompactRecords is a count of records appended to jobs journal over alive jobs to compact it
+const journalCompactRecords = 10000
+
+// journalJobStore is keeping jobs in append-only JSON-lines file
+type journalJobStore struct {
+	mu   sync.Mutex
+	path string
+	file *os.File
+	jobs []*Job
+	// records is a count of journal records, journal is compacted when it reaches compactAt
+	records   int
+	compactAt int
+}
+
+// openJournalJobStore will replay existing journal and compact it to the alive jobs only
+func openJournalJobStore(path string) (*journalJobStore, error) {
+	jobs, err := replayJournal(path)
+	if err != nil {
+		return nil, fmt.Errorf("openJournalJobStore: %w", err)
+	}
+	f, err := rewriteJournal(path, jobs)
+	if err != nil {
+		return nil, fmt.Errorf("openJournalJobStore: %w", err)
+	}
+	return &journalJobStore{path: path, file: f, jobs: jobs, records: len(jobs),
+		compactAt: len(jobs) + journalCompactRecords}, nil
+}
+
+// rewriteJournal will replace journal with alive jobs only to drop deleted ones and fold their results.
+// Journal file opened for append is returned
+func rewriteJournal(path string, jobs []*Job) (*os.File, error) {
+	tmpPath := path + ".tmp"
+	tmp, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("rewriteJournal: %w", err)
+	}
+	for _, v := range jobs {
+		if err := writeJournalRecord(tmp, &journalRecord{Op: journalOpCreate, ID: v.Message.ID, Job: redactedJob(v)}); err != nil {
+			_ = tmp.Close()
+			return nil, fmt.Errorf("rewriteJournal: %w", err)
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, fmt.Errorf("rewriteJournal: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		return nil, fmt.Errorf("rewriteJournal: %w", err)
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("rewriteJournal: %w", err)
+	}
+	return f, nil
+}
+
+// replayJournal will read journal file and rebuild jobs state from it
+func replayJournal(path string) ([]*Job, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("replayJournal: %w", err)
+	}
+
+	var jobs []*Job
+	index := make(map[uuid.UUID]*Job)
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	// Single record holds whole upload request payload
+	scanner.Buffer(make([]byte, 0, 64*1024), int(maxBodySize)*2)
+	for line := 1; scanner.Scan(); line++ {
+		var rec journalRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			// Last record can be partially written if server crashed
+			log.Warnf("skipping broken record at line %d of jobs journal %s: %v", line, path, err)
+			continue
+		}
+		switch rec.Op {
+		case journalOpCreate:
+			if rec.Job == nil || rec.Job.Message == nil {
+				continue
+			}
+			if rec.Job.Results == nil {
+				rec.Job.Results = make(map[string]string)
+			}
+			index[rec.ID] = rec.Job
+			jobs = append(jobs, rec.Job)
+		case journalOpResult:
+			if job, ok := index[rec.ID]; ok {
+				job.Results[rec.Path] = rec.Error
+			}
+		case journalOpComplete:
+			if job, ok := index[rec.ID]; ok {
+				job.Message.Complete = true
+				job.Message.Response = rec.Response
+				job.Message.State = rec.State
+				job.Finished = rec.Time
+				// Journals written before job states were introduced
+				if job.Message.State == "" {
+					job.Message.State = JobDone
+				}
+			}
+		case journalOpDelete:
+			delete(index, rec.ID)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("replayJournal: %w", err)
+	}
+
+	// Keep creation order of jobs
+	alive := jobs[:0]
+	for _, v := range jobs {
+		if job, ok := index[v.Message.ID]; ok && job == v {
+			alive = append(alive, v)
+		}
+	}
+	return alive, nil
+}
+
+func writeJournalRecord(f *os.File, rec *journalRecord) error {
+	rec.Time = time.Now()
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("writeJournalRecord: %w", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		return fmt.Errorf("writeJournalRecord: %w", err)
+	}
+	// Make sure record survives server crash
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("writeJournalRecord: %w", err)
+	}
+	return nil
+}
+
+func (j *journalJobStore) append(rec *journalRecord) error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := writeJournalRecord(j.file, rec); err != nil {
+		return fmt.Errorf("append: %w", err)
+	}
+	// Results and deleted jobs of long-running server are not kept in journal forever
+	if j.records++; j.records >= j.compactAt {
+		if err := j.compact(); err != nil {
+			log.Errorf("unable to compact jobs journal %s: %v", j.path, err)
+		}
+	}
+	return nil
+}
+
+// compact will rewrite journal with alive jobs only
+func (j *journalJobStore) compact() error {
+	jobs, err := replayJournal(j.path)
+	if err != nil {
+		return fmt.Errorf("compact: %w", err)
+	}
+	f, err := rewriteJournal(j.path, jobs)
+	if err != nil {
+		return fmt.Errorf("compact: %w", err)
+	}
+	if err := j.file.Close(); err != nil {
+		log.Errorf("compact: %v", err)
+	}
+	j.file = f
+	j.records = len(jobs)
+	j.compactAt = len(jobs) + journalCompactRecords
+	return nil
+}
+
+func (j *journalJobStore) Create(job *Job) error {
+	return j.append(&journalRecord{Op: journalOpCreate, ID: job.Message.ID, Job: redactedJob(job)})
+}
+
+// redactedJob returns copy of job without destination password and artifacts sources credentials,
+// so they are never written to disk. Destination user and upstream urls are kept to detect lost credentials
+func redactedJob(job *Job) *Job {
+	if job.Components == nil {
+		return job
+	}
+	components := *job.Components
+	components.NexusServer.Password = ""
+	components.Upstreams = nil
+	for _, v := range job.Components.Upstreams {
+		components.Upstreams = append(components.Upstreams, &core.Upstream{URL: v.URL})
+	}
+	redacted := *job
+	redacted.Components = &components
+	return &redacted
+}
+
+func (j *journalJobStore) SaveResult(id uuid.UUID, path string, errText string) error {
+	return j.append(&journalRecord{Op: journalOpResult, ID: id, Path: path, Error: errText})
+}
+
+func (j *journalJobStore) Complete(id uuid.UUID, state JobState, response []string) error {
+	return j.append(&journalRecord{Op: journalOpComplete, ID: id, Response: response, State: state})
+}
+
+func (j *journalJobStore) Delete(id uuid.UUID) error {
+	return j.append(&journalRecord{Op: journalOpDelete, ID: id})
+}
+
+// Load returns jobs which were found in journal at open time
+func (j *journalJobStore) Load() ([]*Job, error) {
+	return j.jobs, nil
+}
+
+func (j *journalJobStore) Close() error {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.file.Close()
+}
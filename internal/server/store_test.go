@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bytes"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"nexus-pusher/internal/core"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func Test_journalJobStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	js, err := openJournalJobStore(path)
+	if err != nil {
+		t.Fatalf("openJournalJobStore() error = %v", err)
+	}
+
+	newJob := func() *Job {
+		return &Job{
+			Message:    &Message{ID: uuid.New()},
+			Repository: "repo1",
+			User:       "user1",
+			Components: &core.NexusExportComponents{
+				NexusServer: core.NexusServer{Host: "https://nexus.some"},
+				Items: []*core.NexusExportComponent{
+					{Name: "name1", Version: "1.0", Format: "npm"},
+				},
+			},
+			Results: make(map[string]string),
+		}
+	}
+	job1, job2, job3 := newJob(), newJob(), newJob()
+	// Credentials sent by client are never written to disk
+	job1.Components.NexusServer.Username = "user"
+	job1.Components.NexusServer.Password = "dst-secret"
+	job1.Components.Upstreams = []*core.Upstream{{URL: "https://npm.some", Auth: &core.UpstreamAuth{Token: "src-secret"}}}
+	for _, v := range []*Job{job1, job2, job3} {
+		if err := js.Create(v); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := js.SaveResult(job1.Message.ID, "path/file1.tgz", ""); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	if err := js.SaveResult(job1.Message.ID, "path/file2.tgz", "some error"); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	if err := js.Complete(job2.Message.ID, JobFailed, []string{"some error"}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if err := js.Delete(job3.Message.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := js.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("dst-secret")) || bytes.Contains(data, []byte("src-secret")) {
+		t.Errorf("journal contains credentials: %s", data)
+	}
+	if job1.Components.NexusServer.Password != "dst-secret" || job1.Components.Upstreams[0].Auth == nil {
+		t.Errorf("Create() must not change credentials of running job")
+	}
+
+	// Simulate crash in the middle of record write
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.WriteString(`{"op":"result","id":"`); err != nil {
+		t.Fatal(err)
+	}
+	_ = f.Close()
+
+	for i := 0; i < 2; i++ {
+		// Reopen twice to check journal compaction too
+		js, err = openJournalJobStore(path)
+		if err != nil {
+			t.Fatalf("openJournalJobStore() error = %v", err)
+		}
+		jobs, err := js.Load()
+		if err != nil {
+			t.Fatalf("Load() error = %v", err)
+		}
+		if len(jobs) != 2 {
+			t.Fatalf("Load() got %d jobs, want 2", len(jobs))
+		}
+		if jobs[0].Message.ID != job1.Message.ID || jobs[1].Message.ID != job2.Message.ID {
+			t.Errorf("Load() got wrong jobs order")
+		}
+		wantResults := map[string]string{"path/file1.tgz": "", "path/file2.tgz": "some error"}
+		if !reflect.DeepEqual(jobs[0].Results, wantResults) {
+			t.Errorf("Load() results = %v, want %v", jobs[0].Results, wantResults)
+		}
+		if jobs[0].Message.Complete || !jobs[1].Message.Complete || jobs[1].Message.State != JobFailed {
+			t.Errorf("Load() got wrong complete flags")
+		}
+		if !reflect.DeepEqual(jobs[1].Message.Response, []string{"some error"}) {
+			t.Errorf("Load() response = %v", jobs[1].Message.Response)
+		}
+		if want := redactedJob(job1).Components; !reflect.DeepEqual(jobs[0].Components, want) {
+			t.Errorf("Load() components = %v, want %v", jobs[0].Components, want)
+		}
+
+		// Only successfully uploaded components must be skipped on resume
+		registry := newJobRegistry(time.Hour)
+		registry.add(jobs[0])
+		// Host of jobs stored before it was recorded is taken from components
+		if jobs[0].User != "user1" || jobs[0].Host != "https://nexus.some" {
+			t.Errorf("Load() user = %s, host = %s", jobs[0].User, jobs[0].Host)
+		}
+		tracker := &jobTracker{id: jobs[0].Message.ID, jobs: registry, store: js}
+		if !tracker.IsUploaded("path/file1.tgz") || tracker.IsUploaded("path/file2.tgz") ||
+			tracker.IsUploaded("path/file3.tgz") {
+			t.Errorf("IsUploaded() got wrong result")
+		}
+		if err := js.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+}
+
+func Test_journalJobStore_compact(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.jsonl")
+	js, err := openJournalJobStore(path)
+	if err != nil {
+		t.Fatalf("openJournalJobStore() error = %v", err)
+	}
+	// Compact journal at the fifth record
+	js.compactAt = 5
+
+	job1 := &Job{Message: &Message{ID: uuid.New()}, Results: make(map[string]string)}
+	job2 := &Job{Message: &Message{ID: uuid.New()}, Results: make(map[string]string)}
+	if err := js.Create(job2); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := js.Delete(job2.Message.ID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if err := js.Create(job1); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, v := range []string{"path/file1.tgz", "path/file2.tgz"} {
+		if err := js.SaveResult(job1.Message.ID, v, ""); err != nil {
+			t.Fatalf("SaveResult() error = %v", err)
+		}
+	}
+
+	// Deleted job and results are folded to single record of alive job
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := bytes.Count(data, []byte("\n")); lines != 1 {
+		t.Errorf("journal has %d records after compaction, want 1", lines)
+	}
+	if err := js.SaveResult(job1.Message.ID, "path/file3.tgz", "some error"); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	if err := js.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	js, err = openJournalJobStore(path)
+	if err != nil {
+		t.Fatalf("openJournalJobStore() error = %v", err)
+	}
+	defer js.Close()
+	jobs, _ := js.Load()
+	want := map[string]string{"path/file1.tgz": "", "path/file2.tgz": "", "path/file3.tgz": "some error"}
+	if len(jobs) != 1 || jobs[0].Message.ID != job1.Message.ID || !reflect.DeepEqual(jobs[0].Results, want) {
+		t.Errorf("Load() got %d jobs after compaction, want job1 with results %v", len(jobs), want)
+	}
+}
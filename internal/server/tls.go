@@ -2,13 +2,16 @@ package server
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	log "github.com/sirupsen/logrus"
 	"golang.org/x/crypto/acme/autocert"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"nexus-pusher/internal/config"
 	"nexus-pusher/internal/core"
+	"nexus-pusher/pkg/utils"
 	"time"
 )
 
@@ -27,9 +30,14 @@ func RunAutoCertServer(cfg *config.Server, v *core.Version) {
 		// },
 	}
 
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		log.Fatalf("unable to setup TLS: %v", err)
+	}
+	tlsConfig.GetCertificate = m.GetCertificate
 	s := &http.Server{
 		Addr:      fmt.Sprintf("%s:%s", cfg.BindAddress, cfg.Port),
-		TLSConfig: &tls.Config{GetCertificate: m.GetCertificate, MinVersion: tls.VersionTLS12},
+		TLSConfig: tlsConfig,
 		Handler:   NewRouter(cfg, v),
 	}
 
@@ -48,12 +56,52 @@ func RunAutoCertServer(cfg *config.Server, v *core.Version) {
 
 // RunStaticCertServer run TLS server with static key/cert provided as a files
 func RunStaticCertServer(cfg *config.Server, v *core.Version) {
-	log.Fatal(http.ListenAndServeTLS(fmt.Sprintf("%s:%s",
-		cfg.BindAddress,
-		cfg.Port),
-		cfg.TLS.CertPath,
-		cfg.TLS.KeyPath,
-		NewRouter(cfg, v)))
+	tlsConfig, err := serverTLSConfig(cfg)
+	if err != nil {
+		log.Fatalf("unable to setup TLS: %v", err)
+	}
+	s := &http.Server{
+		Addr:      fmt.Sprintf("%s:%s", cfg.BindAddress, cfg.Port),
+		TLSConfig: tlsConfig,
+		Handler:   NewRouter(cfg, v),
+	}
+	log.Fatal(s.ListenAndServeTLS(cfg.TLS.CertPath, cfg.TLS.KeyPath))
+}
+
+// serverTLSConfig returns TLS config with client certificate verification following server config
+func serverTLSConfig(cfg *config.Server) (*tls.Config, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.TLS.ClientCAPath == "" {
+		return tlsConfig, nil
+	}
+	// Only certificates issued by configured CA are trusted, system roots are not used
+	data, err := ioutil.ReadFile(cfg.TLS.ClientCAPath)
+	if err != nil {
+		return nil, fmt.Errorf("serverTLSConfig: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, &utils.ContextError{
+			Context: "serverTLSConfig",
+			Err:     fmt.Errorf("no certificates found in %s", cfg.TLS.ClientCAPath),
+		}
+	}
+	tlsConfig.ClientCAs = pool
+	switch cfg.TLS.ClientAuth {
+	case config.TLSClientAuthRequire:
+		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	case config.TLSClientAuthOptional:
+		tlsConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+	return tlsConfig, nil
+}
+
+// certUser returns username from subject common name of verified client certificate
+func certUser(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return ""
+	}
+	return r.TLS.VerifiedChains[0][0].Subject.CommonName
 }
 
 func makeServerFromMux(mux *http.ServeMux) *http.Server {
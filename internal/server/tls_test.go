@@ -0,0 +1,132 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"nexus-pusher/internal/config"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newCert returns self-signed certificate with common name
+func newCert(t *testing.T, commonName string) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+// withClientCert returns request as it's received over TLS with verified client certificate
+func withClientCert(r *http.Request, cert *x509.Certificate) *http.Request {
+	r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert}}}
+	return r
+}
+
+func Test_serverTLSConfig(t *testing.T) {
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	ca := newCert(t, "ca")
+	if err := ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	emptyPath := filepath.Join(dir, "empty.pem")
+	if err := ioutil.WriteFile(emptyPath, []byte("no certs"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name       string
+		caPath     string
+		clientAuth string
+		want       tls.ClientAuthType
+		wantErr    bool
+	}{
+		{"no client CA", "", config.TLSClientAuthNone, tls.NoClientCert, false},
+		{"require", caPath, config.TLSClientAuthRequire, tls.RequireAndVerifyClientCert, false},
+		{"optional", caPath, config.TLSClientAuthOptional, tls.VerifyClientCertIfGiven, false},
+		{"CA without certificates", emptyPath, config.TLSClientAuthRequire, tls.NoClientCert, true},
+		{"missing CA", filepath.Join(dir, "missing.pem"), config.TLSClientAuthRequire, tls.NoClientCert, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Server{}
+			cfg.TLS.ClientCAPath = tt.caPath
+			cfg.TLS.ClientAuth = tt.clientAuth
+			got, err := serverTLSConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("serverTLSConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got.ClientAuth != tt.want {
+				t.Errorf("serverTLSConfig() ClientAuth = %v, want %v", got.ClientAuth, tt.want)
+			}
+			if got.MinVersion != tls.VersionTLS12 {
+				t.Errorf("serverTLSConfig() MinVersion = %v", got.MinVersion)
+			}
+		})
+	}
+}
+
+func Test_webService_certAuth(t *testing.T) {
+	u := newWebService(&config.Server{Credentials: map[string]string{"user1": "pass"}},
+		newJobRegistry(time.Hour), &memoryJobStore{}, newHMACKeySet([]byte("key")), nil)
+	cert := newCert(t, "team1")
+
+	// User without password is logged in with certificate subject
+	req := withClientCert(httptest.NewRequest("GET", config.URIBase+config.URILogin, nil), cert)
+	w := httptest.NewRecorder()
+	u.signInMiddle(http.HandlerFunc(stub)).ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("signInMiddle() status = %d, want %d", w.Code, http.StatusOK)
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		t.Fatalf("signInMiddle() no cookie is set")
+	}
+
+	authorized := func(r *http.Request) (int, string) {
+		r.AddCookie(cookies[0])
+		w := httptest.NewRecorder()
+		var user string
+		u.authMiddle(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user = requestUser(r)
+		})).ServeHTTP(w, r)
+		return w.Code, user
+	}
+	if code, user := authorized(withClientCert(httptest.NewRequest("GET", config.URIBase+config.URIJobs, nil),
+		cert)); code != http.StatusOK || user != "team1" {
+		t.Errorf("authMiddle() status = %d, user = %s, want %d, team1", code, user, http.StatusOK)
+	}
+	// Token can't be used with certificate of another user
+	if code, _ := authorized(withClientCert(httptest.NewRequest("GET", config.URIBase+config.URIJobs, nil),
+		newCert(t, "team2"))); code != http.StatusUnauthorized {
+		t.Errorf("authMiddle() status = %d, want %d", code, http.StatusUnauthorized)
+	}
+}
@@ -3,21 +3,22 @@ package server
 import (
 	"fmt"
 	"github.com/google/uuid"
-	"nexus-pusher/pkg/utils"
+	log "github.com/sirupsen/logrus"
 )
 
 func (u *webService) searchById(id uuid.UUID) (*Message, error) {
-	if msg, ok := u.messages[id]; ok {
-		return msg, nil
-	}
-	return nil, &utils.ContextError{
-		Context: "searchById",
-		Err:     fmt.Errorf("id %v not found", id),
+	msg, err := u.jobs.message(id)
+	if err != nil {
+		return nil, fmt.Errorf("searchById: %w", err)
 	}
+	return msg, nil
 }
 
 func (u *webService) deleteById(id uuid.UUID) {
-	delete(u.messages, id)
+	u.jobs.remove(id)
+	if err := u.store.Delete(id); err != nil {
+		log.Errorf("deleteById: %v", err)
+	}
 }
 
 func (u *webService) genMessageWithId() (*Message, error) {
@@ -26,25 +27,35 @@ func (u *webService) genMessageWithId() (*Message, error) {
 	if err != nil {
 		return nil, fmt.Errorf("genMessageWithId: %w", err)
 	}
-	// Save to data
-	m := &Message{
+	return &Message{
 		ID:       id,
+		State:    JobQueued,
 		Response: nil,
-	}
-	u.messages[id] = m
-	return m, nil
+	}, nil
 }
 
-// completeById set complete flag to message which returned to client
-func (u *webService) completeById(id uuid.UUID, textResult []string) error {
-	// Search in map
-	msg, err := u.searchById(id)
+// cancelById will abort job uploads
+func (u *webService) cancelById(id uuid.UUID) error {
+	finished, err := u.jobs.cancel(id)
 	if err != nil {
-		return fmt.Errorf("completeById: %w", err)
+		return fmt.Errorf("cancelById: %w", err)
+	}
+	// Running job is completed by its runner
+	if finished {
+		if err := u.store.Complete(id, JobCancelled, nil); err != nil {
+			return fmt.Errorf("cancelById: %w", err)
+		}
 	}
-	// Set complete flag
-	msg.Complete = true
-	msg.Response = textResult
+	return nil
+}
 
+// completeById set finished state to message which returned to client
+func (u *webService) completeById(id uuid.UUID, state JobState, textResult []string) error {
+	if err := u.jobs.complete(id, state, textResult); err != nil {
+		return fmt.Errorf("completeById: %w", err)
+	}
+	if err := u.store.Complete(id, state, textResult); err != nil {
+		return fmt.Errorf("completeById: %w", err)
+	}
 	return nil
 }
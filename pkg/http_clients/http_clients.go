@@ -1,6 +1,7 @@
 package http_clients
 
 import (
+	"crypto/tls"
 	"github.com/hashicorp/go-retryablehttp"
 	log "github.com/sirupsen/logrus"
 	"net/http"
@@ -11,6 +12,12 @@ import (
 // HttpRetryClient returns http client with optional timeout parameter
 // Default timeout value is 10 seconds
 func HttpRetryClient(seconds ...int) *http.Client {
+	return HttpRetryClientTLS(nil, seconds...)
+}
+
+// HttpRetryClientTLS returns http client with TLS config and optional timeout parameter
+// Default TLS config is used if tlsConfig is nil. Default timeout value is 10 seconds
+func HttpRetryClientTLS(tlsConfig *tls.Config, seconds ...int) *http.Client {
 	retryClient := retryablehttp.NewClient()
 	retryClient.HTTPClient.Transport = &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
@@ -18,6 +25,7 @@ func HttpRetryClient(seconds ...int) *http.Client {
 		MaxConnsPerHost:     100,
 		MaxIdleConns:        100,
 		DisableKeepAlives:   true,
+		TLSClientConfig:     tlsConfig,
 	}
 
 	customLogger := &logger.CustomRetryLogger{Logger: log.StandardLogger()}
@@ -36,6 +44,12 @@ func HttpRetryClient(seconds ...int) *http.Client {
 // HttpClient returns http client with optional timeout parameter
 // Default timeout value is 10 seconds
 func HttpClient(seconds ...int) *http.Client {
+	return HttpClientTLS(nil, seconds...)
+}
+
+// HttpClientTLS returns http client with TLS config and optional timeout parameter
+// Default TLS config is used if tlsConfig is nil. Default timeout value is 10 seconds
+func HttpClientTLS(tlsConfig *tls.Config, seconds ...int) *http.Client {
 	c := &http.Client{}
 	c.Transport = &http.Transport{
 		Proxy:               http.ProxyFromEnvironment,
@@ -43,6 +57,7 @@ func HttpClient(seconds ...int) *http.Client {
 		MaxConnsPerHost:     100,
 		MaxIdleConns:        100,
 		DisableKeepAlives:   true,
+		TLSClientConfig:     tlsConfig,
 	}
 
 	if len(seconds) != 0 {
@@ -0,0 +1,69 @@
+package http_clients
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"nexus-pusher/pkg/utils"
+)
+
+// TLSOptions is defines TLS options of client connection
+type TLSOptions struct {
+	// CAPath is a PEM encoded CA bundle trusted in addition to system roots
+	CAPath string
+	// CertPath and KeyPath is a client certificate presented to server
+	CertPath string
+	KeyPath  string
+	// InsecureSkipVerify disables server certificate verification
+	InsecureSkipVerify bool
+	// ServerName overrides server name used for SNI and certificate verification
+	ServerName string
+}
+
+// TLSConfig returns client TLS config following options. Nil is returned if no options are set,
+// so default transport config is used
+func TLSConfig(o TLSOptions) (*tls.Config, error) {
+	if o == (TLSOptions{}) {
+		return nil, nil
+	}
+	cfg := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         o.ServerName,
+		InsecureSkipVerify: o.InsecureSkipVerify, // nolint:gosec // explicitly requested in config
+	}
+	if o.CAPath != "" {
+		pool, err := CertPool(o.CAPath)
+		if err != nil {
+			return nil, fmt.Errorf("TLSConfig: %w", err)
+		}
+		cfg.RootCAs = pool
+	}
+	if o.CertPath != "" || o.KeyPath != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertPath, o.KeyPath)
+		if err != nil {
+			return nil, fmt.Errorf("TLSConfig: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// CertPool returns system cert pool with certificates of PEM encoded CA bundle added
+func CertPool(caPath string) (*x509.CertPool, error) {
+	data, err := ioutil.ReadFile(caPath)
+	if err != nil {
+		return nil, fmt.Errorf("CertPool: %w", err)
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil || pool == nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, &utils.ContextError{
+			Context: "CertPool",
+			Err:     fmt.Errorf("no certificates found in %s", caPath),
+		}
+	}
+	return pool, nil
+}